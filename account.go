@@ -0,0 +1,110 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+const (
+	getAccountBalancePath  = "/shipping/v1/account/balance"
+	getAccountSettingsPath = "/shipping/v1/account/settings"
+)
+
+type AccountBalance struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// GetAccountBalance returns the remaining prepaid funds of the account.
+func (c *DHLClient) GetAccountBalance(ctx context.Context) (_ *AccountBalance, err error) {
+	defer wrapOp(&err, opGetBalance)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(getAccountBalancePath), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	var balance AccountBalance
+	if err := json.NewDecoder(resp.Body).Decode(&balance); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return &balance, nil
+}
+
+// AccountSettings are the defaults configured for the account in the DHL
+// portal.
+type AccountSettings struct {
+	DefaultProductCode ProductCode `json:"defaultProductCode,omitempty"`
+	DefaultLabelFormat LabelFormat `json:"defaultLabelFormat,omitempty"`
+}
+
+func (c *DHLClient) GetAccountSettings(ctx context.Context) (_ *AccountSettings, err error) {
+	defer wrapOp(&err, opGetSettings)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(getAccountSettingsPath), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	var settings AccountSettings
+	if err := json.NewDecoder(resp.Body).Decode(&settings); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return &settings, nil
+}
+
+// ApplyAccountDefaults fetches the account settings and makes the portal's
+// default product the client's default, replacing WithDefaultProductCode.
+// It is safe to call while orders are being created, e.g. periodically to
+// pick up changes made in the portal. The default label format needs no
+// local counterpart: labels requested without a format already come in it.
+func (c *DHLClient) ApplyAccountDefaults(ctx context.Context) (err error) {
+	defer wrapOp(&err, opGetSettings)
+
+	settings, err := c.GetAccountSettings(ctx)
+	if err != nil {
+		return err
+	}
+
+	if settings.DefaultProductCode != "" {
+		c.defaultsMu.Lock()
+		c.defaults.productCode = settings.DefaultProductCode
+		c.defaultsMu.Unlock()
+	}
+
+	return nil
+}
@@ -0,0 +1,232 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+const (
+	createBatchPath    = "/shipping/v1/orders/batches"
+	getBatchStatusPath = "/shipping/v1/orders/batches/%s"
+
+	// maxBatchSize is the most orders DHL accepts in one batch request.
+	maxBatchSize = 100
+
+	batchPollMinInterval = 5 * time.Second
+	batchPollMaxInterval = time.Minute
+)
+
+type BatchJobStatus string
+
+const (
+	BatchPending    BatchJobStatus = "PENDING"
+	BatchProcessing BatchJobStatus = "PROCESSING"
+	BatchCompleted  BatchJobStatus = "COMPLETED"
+	BatchFailed     BatchJobStatus = "FAILED"
+)
+
+type BatchOrderResult struct {
+	Reference string `json:"reference,omitempty"`
+	OrderID   string `json:"orderId,omitempty"`
+	Error     string `json:"error,omitempty"`
+}
+
+type BatchStatus struct {
+	JobID   string             `json:"jobId"`
+	Status  BatchJobStatus     `json:"status"`
+	Results []BatchOrderResult `json:"results"`
+}
+
+func (c *DHLClient) GetBatchStatus(ctx context.Context, jobID string) (_ *BatchStatus, err error) {
+	defer wrapOp(&err, opGetBatch)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(getBatchStatusPath, jobID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	var status BatchStatus
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return &status, nil
+}
+
+// WaitForBatch polls GetBatchStatus until the job completes or fails and
+// returns the per-order results. A failed job returns its results along with
+// an error.
+func (c *DHLClient) WaitForBatch(ctx context.Context, jobID string) (_ []BatchOrderResult, err error) {
+	defer wrapOp(&err, opWaitBatch)
+
+	interval := batchPollMinInterval
+	for {
+		status, err := c.GetBatchStatus(ctx, jobID)
+		if err != nil {
+			return nil, err
+		}
+
+		switch status.Status {
+		case BatchCompleted:
+			return status.Results, nil
+		case BatchFailed:
+			return status.Results, fmt.Errorf("batch %s failed", jobID)
+		}
+
+		if err := c.sleep(ctx, interval); err != nil {
+			return nil, err
+		}
+		if interval *= 2; interval > batchPollMaxInterval {
+			interval = batchPollMaxInterval
+		}
+	}
+}
+
+type createBatchRequest struct {
+	Orders []Order `json:"orders"`
+}
+
+type createBatchResponse struct {
+	JobID string `json:"jobId"`
+}
+
+func (c *DHLClient) submitBatch(ctx context.Context, orders []Order) (string, error) {
+	jsonData, err := c.marshal(createBatchRequest{Orders: orders})
+	if err != nil {
+		return "", fmt.Errorf("marshaling batch: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(createBatchPath), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return "", err
+	}
+	req.Header.Add("Content-Type", c.contentType)
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, jsonData)
+	if err != nil {
+		return "", fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusCreated {
+		return "", newAPIError(resp)
+	}
+
+	var batchResp createBatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&batchResp); err != nil {
+		return "", fmt.Errorf("decoding response: %w", err)
+	}
+
+	return batchResp.JobID, nil
+}
+
+// CreateOrdersChunked creates the orders through DHL's batch endpoint,
+// submitting them one chunk of chunkSize orders at a time and waiting for
+// each batch to finish. chunkSize defaults to, and is capped at, DHL's limit
+// of 100 orders per batch. Orders failing validation or not covered by the
+// token's product scopes are reported without being sent. The result holds
+// one entry per order in input order. Created orders are recorded without
+// tracking numbers, which batch results lack.
+func (c *DHLClient) CreateOrdersChunked(ctx context.Context, orders []Order, chunkSize int) (_ *BatchResult, err error) {
+	defer wrapOp(&err, opCreateOrders)
+
+	if chunkSize <= 0 || chunkSize > maxBatchSize {
+		chunkSize = maxBatchSize
+	}
+
+	result := &BatchResult{Results: make([]BatchItemResult, len(orders))}
+
+	var valid []Order
+	var positions []int
+	for i, order := range orders {
+		result.Results[i] = BatchItemResult{Index: i, Reference: order.Reference}
+
+		order, err := c.applyDefaults(order).normalize(c.now())
+		if err != nil {
+			result.Results[i].Err = fmt.Errorf("%w: %w", ErrInvalidOrder, err)
+			continue
+		}
+		if err := c.checkProductScope(ctx, order.ProductCode); err != nil {
+			result.Results[i].Err = err
+			continue
+		}
+		valid = append(valid, order)
+		positions = append(positions, i)
+	}
+
+	for start := 0; start < len(valid); start += chunkSize {
+		end := min(start+chunkSize, len(valid))
+		c.createChunk(ctx, valid[start:end], positions[start:end], result)
+	}
+
+	return result, result.err()
+}
+
+// createChunk submits one batch and records its outcome at positions in
+// result. DHL reports batch results in submission order.
+func (c *DHLClient) createChunk(ctx context.Context, chunk []Order, positions []int, result *BatchResult) {
+	fail := func(err error) {
+		for _, i := range positions {
+			result.Results[i].Err = err
+		}
+	}
+
+	jobID, err := c.submitBatch(ctx, chunk)
+	if err != nil {
+		fail(err)
+		return
+	}
+
+	results, err := c.WaitForBatch(ctx, jobID)
+	if len(results) != len(chunk) {
+		if err == nil {
+			err = fmt.Errorf("batch %s returned %d results for %d orders", jobID, len(results), len(chunk))
+		}
+		fail(err)
+		return
+	}
+
+	for n, batchResult := range results {
+		item := &result.Results[positions[n]]
+		switch {
+		case batchResult.Error != "":
+			item.Err = fmt.Errorf("batch %s: %s", jobID, batchResult.Error)
+		case batchResult.OrderID == "" && err != nil:
+			// A failed batch may list orders it never created.
+			item.Err = err
+		case batchResult.OrderID == "":
+			item.Err = fmt.Errorf("batch %s returned no order id", jobID)
+		}
+		if item.Err != nil {
+			continue
+		}
+		item.OrderID = batchResult.OrderID
+		if c.recorder != nil {
+			c.recorder.record(&CreateOrderResponse{OrderID: batchResult.OrderID}, chunk[n].Reference, c.now())
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting DHL while the circuit breaker
+// configured with WithCircuitBreaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker open")
+
+// WithCircuitBreaker opens the circuit after failures consecutive failed
+// calls, i.e. transport errors and 5xx responses. While open, calls fail
+// fast with ErrCircuitOpen. After a cooldown, jittered by up to ±20%, a single
+// probe call is let through: its success closes the circuit, its failure
+// opens it again.
+func WithCircuitBreaker(failures int, cooldown time.Duration) Option {
+	return func(c *DHLClient) {
+		if failures > 0 {
+			c.breaker = &circuitBreaker{threshold: failures, cooldown: cooldown}
+		}
+	}
+}
+
+type circuitBreaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allowCall reports whether a call may be sent and whether it is the
+// half-open probe, which must be passed on to recordCall.
+func (c *DHLClient) allowCall() (probe bool, err error) {
+	b := c.breaker
+	if b == nil {
+		return false, nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.threshold {
+		return false, nil
+	}
+	if b.probing || c.now().Before(b.openUntil) {
+		return false, ErrCircuitOpen
+	}
+	b.probing = true
+	return true, nil
+}
+
+func (c *DHLClient) recordCall(probe bool, resp *http.Response, err error, canceled bool) {
+	b := c.breaker
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	// Calls that were in flight when the circuit opened must not end the
+	// probe.
+	if probe {
+		b.probing = false
+	}
+
+	switch {
+	case canceled:
+		return
+	case err == nil && resp.StatusCode < http.StatusInternalServerError:
+		b.failures = 0
+		return
+	}
+
+	b.failures++
+	if b.failures >= b.threshold {
+		b.openUntil = c.now().Add(b.jitteredCooldown(c.jitter))
+	}
+}
+
+func (b *circuitBreaker) jitteredCooldown(jitter *jitterSource) time.Duration {
+	spread := int64(b.cooldown) * 2 / 5
+	if spread <= 0 {
+		return b.cooldown
+	}
+	return b.cooldown - time.Duration(spread/2) + time.Duration(jitter.int63n(spread+1))
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerLateCallDoesNotEndProbe(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	c, err := NewDHLClient("id", "secret", WithCircuitBreaker(1, time.Minute), WithClock(func() time.Time { return now }))
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed := &http.Response{StatusCode: http.StatusServiceUnavailable}
+
+	// A call starts while the circuit is closed and is still in flight when
+	// another call's failure opens it.
+	late, err := c.allowCall()
+	if err != nil || late {
+		t.Fatalf("closed circuit: allowCall() = %v, %v", late, err)
+	}
+	c.recordCall(false, failed, nil, false)
+
+	now = now.Add(2 * time.Minute)
+	probe, err := c.allowCall()
+	if err != nil || !probe {
+		t.Fatalf("after cooldown: allowCall() = %v, %v; want the probe", probe, err)
+	}
+
+	c.recordCall(late, failed, nil, false)
+	if _, err := c.allowCall(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("second call during the probe: err = %v, want ErrCircuitOpen", err)
+	}
+
+	c.recordCall(probe, &http.Response{StatusCode: http.StatusOK}, nil, false)
+	if _, err := c.allowCall(); err != nil {
+		t.Fatalf("after a successful probe: err = %v", err)
+	}
+}
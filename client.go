@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"image"
+	"io"
+	"time"
+)
+
+// Client is the set of operations implemented by *DHLClient. Depend on it
+// instead of the concrete type to substitute fakes in tests.
+type Client interface {
+	Environment() Environment
+	Events() <-chan Event
+
+	GetAccessToken(ctx context.Context) error
+	FetchToken(ctx context.Context) (TokenResponse, error)
+	UpdateCredentials(clientID, clientSecret string)
+	CurrentToken() (string, time.Time, bool)
+	TokenScopes() []string
+
+	CreateOrder(ctx context.Context, order Order) (string, error)
+	CreateOrderResult(ctx context.Context, order Order) (*OrderResult, error)
+	PreviewOrder(ctx context.Context, order Order) (*OrderPreview, error)
+	CreateOrders(ctx context.Context, orders []Order) (*BatchResult, error)
+	CreateOrdersChunked(ctx context.Context, orders []Order, chunkSize int) (*BatchResult, error)
+	CreateOrderRaw(ctx context.Context, orderData map[string]interface{}) (string, error)
+	CreateOrderIfAbsent(ctx context.Context, order Order) (string, bool, error)
+	CreateLabel(ctx context.Context, order Order) (*CreatedLabel, error)
+	SaveLabel(dir string, info LabelFileInfo, label *Label) (string, error)
+	CreateAndSaveLabel(ctx context.Context, order Order, dir string) (string, error)
+	PollLocation(ctx context.Context, location string) (*CreateOrderResponse, error)
+	GetOrder(ctx context.Context, orderID string) (*OrderDetails, error)
+	DeleteOrder(ctx context.Context, orderID string) error
+	DeleteOrders(ctx context.Context, orderIDs []string) ([]DeleteOrderResult, error)
+	VoidShipment(ctx context.Context, itemID string) (*VoidConfirmation, error)
+	WatchOrder(ctx context.Context, orderID string) (<-chan OrderStatus, error)
+	WatchOrderUpdates(ctx context.Context, orderID string) (<-chan OrderUpdate, error)
+	ListOrders(ctx context.Context, filter OrderFilter) ([]OrderDetails, error)
+	ListOrdersByDateRange(ctx context.Context, from, to time.Time) ([]OrderDetails, error)
+	GetOrdersByReferences(ctx context.Context, refs []string) (map[string]OrderDetails, []string, error)
+	CheckServiceability(ctx context.Context, code ProductCode, destination Address) (*Serviceability, error)
+	ListProducts(ctx context.Context) ([]ProductCode, error)
+	GetBatchStatus(ctx context.Context, jobID string) (*BatchStatus, error)
+	WaitForBatch(ctx context.Context, jobID string) ([]BatchOrderResult, error)
+	GetTrackingStatus(ctx context.Context, trackingNumber string) (*TrackingStatus, error)
+	GetProofOfDelivery(ctx context.Context, trackingNumber string) (*Document, error)
+
+	GetItem(ctx context.Context, itemID string) (*ItemDetails, error)
+	GetItemLabel(ctx context.Context, itemID string) ([]byte, error)
+	GetLabel(ctx context.Context, itemID string, opts LabelOptions) (*Label, error)
+	GetShipmentLabel(ctx context.Context, awb string, opts LabelOptions) (*Label, error)
+	GetLabelByTrackingNumber(ctx context.Context, trackingNumber string, format LabelFormat) (*Label, error)
+	GetItemLabelImage(ctx context.Context, itemID string) (image.Image, error)
+	GetItemLabelWithSidecar(ctx context.Context, itemID string) (*Label, *LabelMetadata, error)
+	DownloadLabelsZip(ctx context.Context, itemIDs []string, w io.Writer) error
+	GenerateOrderPacket(ctx context.Context, itemID string) ([]byte, error)
+	RenderZPLToPNG(ctx context.Context, zpl []byte) ([]byte, error)
+	RenderZPLToPNGAtDPI(ctx context.Context, zpl []byte, dpi int) ([]byte, error)
+	GetCustomsInvoice(ctx context.Context, itemID string, format LabelFormat) ([]byte, error)
+
+	GetAccountBalance(ctx context.Context) (*AccountBalance, error)
+	GetAccountSettings(ctx context.Context) (*AccountSettings, error)
+	ApplyAccountDefaults(ctx context.Context) error
+	CreateWebhookSubscription(ctx context.Context, endpoint string, events []string) (string, error)
+	DeleteWebhookSubscription(ctx context.Context, id string) error
+	ResetSandbox(ctx context.Context) error
+}
+
+var _ Client = (*DHLClient)(nil)
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/mail"
+	"strings"
+)
+
+type contact struct {
+	Email string `json:"email,omitempty"`
+	Phone string `json:"phone,omitempty"`
+}
+
+type receiverDetailsJSON struct {
+	Name    Name     `json:"name"`
+	Address Address  `json:"address"`
+	Contact *contact `json:"contact,omitempty"`
+}
+
+func (r ReceiverDetails) MarshalJSON() ([]byte, error) {
+	out := receiverDetailsJSON{Name: r.Name, Address: r.Address}
+	if r.Email != "" || r.Phone != "" {
+		out.Contact = &contact{Email: r.Email, Phone: r.Phone}
+	}
+	return json.Marshal(out)
+}
+
+func (r *ReceiverDetails) UnmarshalJSON(data []byte) error {
+	var in receiverDetailsJSON
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	*r = ReceiverDetails{Name: in.Name, Address: in.Address}
+	if in.Contact != nil {
+		r.Email, r.Phone = in.Contact.Email, in.Contact.Phone
+	}
+	return nil
+}
+
+func (r ReceiverDetails) validateContact() error {
+	if r.Email != "" {
+		addr, err := mail.ParseAddress(r.Email)
+		if err != nil || addr.Address != r.Email || !strings.Contains(addr.Address[strings.LastIndex(addr.Address, "@"):], ".") {
+			return fmt.Errorf("email %q is not a plain email address", r.Email)
+		}
+	}
+	if r.Phone != "" {
+		if err := validatePhone(r.Phone); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validatePhone accepts the digits of a phone number with an optional leading
+// "+" and the usual separators.
+func validatePhone(phone string) error {
+	digits := 0
+	for i, r := range phone {
+		switch {
+		case r >= '0' && r <= '9':
+			digits++
+		case r == '+' && i == 0:
+		case strings.ContainsRune(" -/().", r):
+		default:
+			return fmt.Errorf("phone %q contains invalid character %q", phone, r)
+		}
+	}
+	if digits < 6 || digits > 15 {
+		return fmt.Errorf("phone %q must have 6 to 15 digits, got %d", phone, digits)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+type countryInfo struct {
+	alpha2 string
+	alpha3 string
+	names  []string
+}
+
+var countries = []countryInfo{
+	{"AT", "AUT", []string{"austria", "österreich", "oesterreich"}},
+	{"AU", "AUS", []string{"australia", "australien"}},
+	{"BE", "BEL", []string{"belgium", "belgien", "belgique"}},
+	{"BG", "BGR", []string{"bulgaria", "bulgarien"}},
+	{"BR", "BRA", []string{"brazil", "brasilien"}},
+	{"CA", "CAN", []string{"canada", "kanada"}},
+	{"CH", "CHE", []string{"switzerland", "schweiz", "suisse"}},
+	{"CN", "CHN", []string{"china"}},
+	{"CY", "CYP", []string{"cyprus", "zypern"}},
+	{"CZ", "CZE", []string{"czech republic", "czechia", "tschechien"}},
+	{"DE", "DEU", []string{"germany", "deutschland"}},
+	{"DK", "DNK", []string{"denmark", "dänemark", "daenemark"}},
+	{"EE", "EST", []string{"estonia", "estland"}},
+	{"ES", "ESP", []string{"spain", "spanien", "españa"}},
+	{"FI", "FIN", []string{"finland", "finnland"}},
+	{"FR", "FRA", []string{"france", "frankreich"}},
+	{"GB", "GBR", []string{"united kingdom", "great britain", "uk", "england", "großbritannien", "grossbritannien", "vereinigtes königreich"}},
+	{"GR", "GRC", []string{"greece", "griechenland"}},
+	{"HK", "HKG", []string{"hong kong", "hongkong"}},
+	{"HR", "HRV", []string{"croatia", "kroatien"}},
+	{"HU", "HUN", []string{"hungary", "ungarn"}},
+	{"IE", "IRL", []string{"ireland", "irland"}},
+	{"IL", "ISR", []string{"israel"}},
+	{"IN", "IND", []string{"india", "indien"}},
+	{"IS", "ISL", []string{"iceland", "island"}},
+	{"IT", "ITA", []string{"italy", "italien", "italia"}},
+	{"JP", "JPN", []string{"japan"}},
+	{"KR", "KOR", []string{"south korea", "korea", "südkorea"}},
+	{"LI", "LIE", []string{"liechtenstein"}},
+	{"LT", "LTU", []string{"lithuania", "litauen"}},
+	{"LU", "LUX", []string{"luxembourg", "luxemburg"}},
+	{"LV", "LVA", []string{"latvia", "lettland"}},
+	{"MT", "MLT", []string{"malta"}},
+	{"MX", "MEX", []string{"mexico", "mexiko"}},
+	{"NL", "NLD", []string{"netherlands", "the netherlands", "holland", "niederlande"}},
+	{"NO", "NOR", []string{"norway", "norwegen"}},
+	{"NZ", "NZL", []string{"new zealand", "neuseeland"}},
+	{"PL", "POL", []string{"poland", "polen"}},
+	{"PT", "PRT", []string{"portugal"}},
+	{"RO", "ROU", []string{"romania", "rumänien", "rumaenien"}},
+	{"RS", "SRB", []string{"serbia", "serbien"}},
+	{"SE", "SWE", []string{"sweden", "schweden"}},
+	{"SG", "SGP", []string{"singapore", "singapur"}},
+	{"SI", "SVN", []string{"slovenia", "slowenien"}},
+	{"SK", "SVK", []string{"slovakia", "slowakei"}},
+	{"TR", "TUR", []string{"turkey", "türkei", "tuerkei", "türkiye"}},
+	{"UA", "UKR", []string{"ukraine"}},
+	{"US", "USA", []string{"united states", "united states of america", "usa", "america", "vereinigte staaten"}},
+	{"ZA", "ZAF", []string{"south africa", "südafrika"}},
+}
+
+var countryLookup = buildCountryLookup()
+
+func buildCountryLookup() map[string]string {
+	lookup := make(map[string]string)
+	for _, country := range countries {
+		lookup[strings.ToLower(country.alpha2)] = country.alpha2
+		lookup[strings.ToLower(country.alpha3)] = country.alpha2
+		for _, name := range country.names {
+			lookup[name] = country.alpha2
+		}
+	}
+	return lookup
+}
+
+// NormalizeCountry maps an ISO-3166 alpha-2 or alpha-3 code or a common
+// country name to the alpha-2 code DHL expects.
+func NormalizeCountry(country string) (string, error) {
+	key := strings.ToLower(strings.Join(strings.Fields(country), " "))
+	if key == "" {
+		return "", fmt.Errorf("country is empty")
+	}
+	if alpha2, ok := countryLookup[key]; ok {
+		return alpha2, nil
+	}
+	return "", fmt.Errorf("unknown country %q", country)
+}
@@ -0,0 +1,36 @@
+package main
+
+import "testing"
+
+func TestNormalizeCountry(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{input: "DE", want: "DE"},
+		{input: "de", want: "DE"},
+		{input: "DEU", want: "DE"},
+		{input: "Germany", want: "DE"},
+		{input: "Deutschland", want: "DE"},
+		{input: "  germany ", want: "DE"},
+		{input: "Österreich", want: "AT"},
+		{input: "FRA", want: "FR"},
+		{input: "United  Kingdom", want: "GB"},
+		{input: "UK", want: "GB"},
+		{input: "gbr", want: "GB"},
+		{input: "USA", want: "US"},
+		{input: "The Netherlands", want: "NL"},
+		{input: "Schweiz", want: "CH"},
+		{input: "Atlantis", wantErr: true},
+		{input: "XX", wantErr: true},
+		{input: "", wantErr: true},
+		{input: "   ", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := NormalizeCountry(tt.input)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("NormalizeCountry(%q) = %q, %v; want %q, error %v", tt.input, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
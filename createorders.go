@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+const createOrdersConcurrency = 4
+
+// BatchItemResult is the outcome of one order of a CreateOrders call. Index
+// is the order's position in the input slice.
+type BatchItemResult struct {
+	Index     int
+	Reference string
+	OrderID   string
+	Err       error
+}
+
+type BatchResult struct {
+	Results []BatchItemResult
+}
+
+type BatchSummary struct {
+	Total     int
+	Succeeded int
+	Failed    int
+	// Errors groups the failures by error type: "invalid_order", "auth",
+	// "canceled", "timeout", "service_unavailable", the DHL error code of
+	// API errors or "http_<status>" when DHL sent none, and "other".
+	Errors map[string][]error
+}
+
+func (r *BatchResult) Summary() BatchSummary {
+	summary := BatchSummary{Total: len(r.Results), Errors: make(map[string][]error)}
+	for _, result := range r.Results {
+		if result.Err == nil {
+			summary.Succeeded++
+			continue
+		}
+		summary.Failed++
+		kind := errorType(result.Err)
+		summary.Errors[kind] = append(summary.Errors[kind], result.Err)
+	}
+	return summary
+}
+
+func (r *BatchResult) err() error {
+	var failures []error
+	for _, item := range r.Results {
+		if item.Err != nil {
+			failures = append(failures, fmt.Errorf("order %d: %w", item.Index, item.Err))
+		}
+	}
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d orders failed: %w", len(failures), len(r.Results), errors.Join(failures...))
+	}
+	return nil
+}
+
+func errorType(err error) string {
+	var apiErr *APIError
+	var authErr *AuthError
+	switch {
+	case errors.Is(err, ErrInvalidOrder):
+		return "invalid_order"
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded):
+		return "timeout"
+	case errors.As(err, &authErr):
+		return "auth"
+	case errors.As(err, &apiErr):
+		if apiErr.Maintenance {
+			return "service_unavailable"
+		}
+		for _, detail := range apiErr.Errors {
+			if detail.Code != "" {
+				return detail.Code
+			}
+		}
+		return fmt.Sprintf("http_%d", apiErr.StatusCode)
+	}
+	return "other"
+}
+
+// CreateOrders creates the orders concurrently. The result holds one entry
+// per order in input order; the error reports the failed ones, if any.
+func (c *DHLClient) CreateOrders(ctx context.Context, orders []Order) (_ *BatchResult, err error) {
+	defer wrapOp(&err, opCreateOrders)
+
+	result := &BatchResult{Results: make([]BatchItemResult, len(orders))}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, createOrdersConcurrency)
+	for i, order := range orders {
+		result.Results[i] = BatchItemResult{Index: i, Reference: order.Reference}
+
+		wg.Add(1)
+		go func(item *BatchItemResult, order Order) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				item.Err = ctx.Err()
+				return
+			}
+			createOrderResp, err := c.createTypedOrder(ctx, order)
+			if err != nil {
+				item.Err = err
+				return
+			}
+			item.OrderID = createOrderResp.OrderID
+		}(&result.Results[i], order)
+	}
+	wg.Wait()
+
+	return result, result.err()
+}
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"strings"
+)
+
+const getCustomsDocumentPath = "/shipping/v1/items/%s/customsdocument"
+
+// Customs is the customs declaration of an international order. Language
+// (ISO 639-1) selects the language DHL renders the customs documents in;
+// empty leaves it to the account's locale. TotalValue is filled in from the
+// items when left nil.
+type Customs struct {
+	Language    string        `json:"language,omitempty"`
+	Items       []CustomsItem `json:"items,omitempty"`
+	TotalValue  *Money        `json:"totalValue,omitempty"`
+	DutyPayment DutyPayment   `json:"dutyPayment,omitempty"`
+}
+
+// DutyPayment says who pays import duties and taxes: the shipper with DDP
+// (delivered duty paid) or the receiver with DDU (delivered duty unpaid).
+// Empty leaves it to DHL, which treats it as DDU. DDP is only accepted by
+// products with ProductCharacteristics.DutiesPaid and for destinations
+// outside the EU customs union.
+type DutyPayment string
+
+const (
+	DutyDDP DutyPayment = "DDP"
+	DutyDDU DutyPayment = "DDU"
+)
+
+// ContentCategory declares what an order contains, which decides how customs
+// handles it. Merchandise needs customs items; documents must not have any.
+type ContentCategory string
+
+const (
+	ContentDocument    ContentCategory = "DOCUMENT"
+	ContentMerchandise ContentCategory = "MERCHANDISE"
+	ContentGift        ContentCategory = "GIFT"
+	ContentSample      ContentCategory = "SAMPLE"
+	ContentReturn      ContentCategory = "RETURN"
+)
+
+func (cc ContentCategory) validate(customs *Customs) error {
+	hasItems := customs != nil && len(customs.Items) > 0
+	switch cc {
+	case ContentMerchandise:
+		if !hasItems {
+			return fmt.Errorf("%s requires customs items", cc)
+		}
+	case ContentDocument:
+		if hasItems {
+			return fmt.Errorf("%s must not have customs items", cc)
+		}
+	case ContentGift, ContentSample, ContentReturn:
+	default:
+		return fmt.Errorf("unsupported value %q", string(cc))
+	}
+	return nil
+}
+
+// euCustomsUnion lists the destinations reached without a customs border
+// from Germany, for which DDP is meaningless.
+var euCustomsUnion = map[string]bool{
+	"AT": true, "BE": true, "BG": true, "CY": true, "CZ": true, "DE": true, "DK": true,
+	"EE": true, "ES": true, "FI": true, "FR": true, "GR": true, "HR": true, "HU": true,
+	"IE": true, "IT": true, "LT": true, "LU": true, "LV": true, "MT": true, "NL": true,
+	"PL": true, "PT": true, "RO": true, "SE": true, "SI": true, "SK": true,
+}
+
+// CustomsItem is one line of a customs declaration.
+type CustomsItem struct {
+	Description   string `json:"description"`
+	Quantity      int    `json:"quantity"`
+	Value         Money  `json:"value"` // of the whole line, not of one unit
+	HSCode        string `json:"hsCode,omitempty"`
+	OriginCountry string `json:"originCountry,omitempty"`
+}
+
+func (cu Customs) normalize() (Customs, error) {
+	switch cu.DutyPayment {
+	case "", DutyDDP, DutyDDU:
+	default:
+		return cu, fmt.Errorf("dutyPayment: unsupported value %q (want %s or %s)", string(cu.DutyPayment), DutyDDP, DutyDDU)
+	}
+
+	if cu.Language != "" {
+		language, err := normalizeLanguage(cu.Language)
+		if err != nil {
+			return cu, fmt.Errorf("language: %w", err)
+		}
+		cu.Language = language
+	}
+
+	items := make([]CustomsItem, len(cu.Items))
+	for i, item := range cu.Items {
+		if item.Description == "" {
+			return cu, fmt.Errorf("items[%d].description is required", i)
+		}
+		if item.Quantity <= 0 {
+			return cu, fmt.Errorf("items[%d].quantity must be positive", i)
+		}
+		if err := item.Value.validate(); err != nil {
+			return cu, fmt.Errorf("items[%d].value: %w", i, err)
+		}
+		if item.OriginCountry != "" {
+			country, err := NormalizeCountry(item.OriginCountry)
+			if err != nil {
+				return cu, fmt.Errorf("items[%d].originCountry: %w", i, err)
+			}
+			item.OriginCountry = country
+		}
+		items[i] = item
+	}
+	if len(items) > 0 {
+		cu.Items = items
+	}
+
+	if cu.TotalValue == nil && len(cu.Items) > 0 {
+		amount, currency, err := cu.TotalDeclaredValue()
+		if err != nil {
+			return cu, err
+		}
+		cu.TotalValue = &Money{Amount: amount, Currency: currency}
+	}
+	if cu.TotalValue != nil {
+		if err := cu.TotalValue.validate(); err != nil {
+			return cu, fmt.Errorf("totalValue: %w", err)
+		}
+	}
+	return cu, nil
+}
+
+// TotalDeclaredValue sums the values of the customs items, rounded to
+// cents. It fails when the items are declared in different currencies.
+func (cu Customs) TotalDeclaredValue() (amount float64, currency string, err error) {
+	for i, item := range cu.Items {
+		itemCurrency := strings.ToUpper(item.Value.Currency)
+		switch {
+		case currency == "":
+			currency = itemCurrency
+		case itemCurrency != currency:
+			return 0, "", fmt.Errorf("items[%d].value: currency %s differs from %s of the other items", i, item.Value.Currency, currency)
+		}
+		amount += item.Value.Amount
+	}
+	return math.Round(amount*100) / 100, currency, nil
+}
+
+func normalizeLanguage(language string) (string, error) {
+	language = strings.ToLower(strings.TrimSpace(language))
+	if len(language) != 2 || !isLetters(language) {
+		return "", fmt.Errorf("%q is not a 2-letter ISO 639-1 language code", language)
+	}
+	return language, nil
+}
+
+// GetCustomsInvoice downloads the commercial invoice / customs declaration
+// DHL generates for an international item.
+func (c *DHLClient) GetCustomsInvoice(ctx context.Context, itemID string, format LabelFormat) (_ []byte, err error) {
+	defer wrapOp(&err, opGetCustoms)
+
+	if format == "" {
+		format = LabelFormatPDF
+	}
+	if format != LabelFormatPDF && format != LabelFormatPNG {
+		return nil, fmt.Errorf("customs documents are only available as %s or %s, not %q", LabelFormatPDF, LabelFormatPNG, string(format))
+	}
+
+	req, err := http.NewRequestWithContext(withOpKind(ctx, opKindLabel), http.MethodGet, c.url(getCustomsDocumentPath, itemID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", format.mediaType())
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	document, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	return document, nil
+}
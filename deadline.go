@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+)
+
+type opDeadlinesKey struct{}
+
+// WithLabelDeadline bounds every label and document download made with ctx
+// to d. Like all per-operation deadlines it only ever tightens ctx's own
+// deadline.
+func WithLabelDeadline(ctx context.Context, d time.Duration) context.Context {
+	return withOpDeadline(ctx, opKindLabel, d)
+}
+
+// WithOrderDeadline bounds every order call (create, get, list, ...) made
+// with ctx to d.
+func WithOrderDeadline(ctx context.Context, d time.Duration) context.Context {
+	return withOpDeadline(ctx, opKindOrder, d)
+}
+
+// WithAuthDeadline bounds every token request made with ctx to d.
+func WithAuthDeadline(ctx context.Context, d time.Duration) context.Context {
+	return withOpDeadline(ctx, opKindAuth, d)
+}
+
+func withOpDeadline(ctx context.Context, kind opKind, d time.Duration) context.Context {
+	deadlines := make(map[opKind]time.Duration)
+	if existing, ok := ctx.Value(opDeadlinesKey{}).(map[opKind]time.Duration); ok {
+		for k, v := range existing {
+			deadlines[k] = v
+		}
+	}
+	deadlines[kind] = d
+	return context.WithValue(ctx, opDeadlinesKey{}, deadlines)
+}
+
+// applyOpDeadline returns req bound to the deadline registered for its
+// operation kind, if any, and the cancel func releasing it.
+func applyOpDeadline(req *http.Request) (*http.Request, context.CancelFunc) {
+	ctx := req.Context()
+	deadlines, _ := ctx.Value(opDeadlinesKey{}).(map[opKind]time.Duration)
+	d, ok := deadlines[opKindFrom(ctx)]
+	if !ok || d <= 0 {
+		return req, func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, d)
+	return req.WithContext(ctx), cancel
+}
+
+// cancelOnClose releases a request's deadline once its response body has
+// been consumed and closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
@@ -0,0 +1,216 @@
+// Package dhltest provides an in-process fake of the DHL DPI API for tests.
+package dhltest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+)
+
+const (
+	basePath = "/dpi"
+
+	AccessToken = "dhltest-access-token"
+)
+
+type Route string
+
+const (
+	RouteAuth        Route = "auth"
+	RouteCreateOrder Route = "create_order"
+	RouteItemLabel   Route = "item_label"
+)
+
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+type Request struct {
+	Route  Route
+	Method string
+	Path   string
+	Header http.Header
+	Body   []byte
+}
+
+type Server struct {
+	*httptest.Server
+
+	mu        sync.Mutex
+	responses map[Route]Response
+	injected  map[Route][]Response
+	requests  []Request
+}
+
+func NewServer() *Server {
+	s := &Server{
+		responses: defaultResponses(),
+		injected:  make(map[Route][]Response),
+	}
+	s.Server = httptest.NewServer(http.HandlerFunc(s.handle))
+	return s
+}
+
+// BaseURL is the URL to pass to the client's WithBaseURL option.
+func (s *Server) BaseURL() string {
+	return s.URL + basePath
+}
+
+// SetResponse replaces the canned response for route.
+func (s *Server) SetResponse(route Route, resp Response) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses[route] = resp
+}
+
+// InjectError makes the next times requests to route fail with status and
+// body before the canned response is served again.
+func (s *Server) InjectError(route Route, status int, body string, times int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := 0; i < times; i++ {
+		s.injected[route] = append(s.injected[route], Response{
+			Status: status,
+			Header: http.Header{"Content-Type": []string{"application/json"}},
+			Body:   []byte(body),
+		})
+	}
+}
+
+func (s *Server) Requests() []Request {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	requests := make([]Request, len(s.requests))
+	copy(requests, s.requests)
+	return requests
+}
+
+func (s *Server) RequestsFor(route Route) []Request {
+	var matched []Request
+	for _, req := range s.Requests() {
+		if req.Route == route {
+			matched = append(matched, req)
+		}
+	}
+	return matched
+}
+
+// AssertReceived fails t unless route received exactly n requests.
+func (s *Server) AssertReceived(t testing.TB, route Route, n int) {
+	t.Helper()
+	if got := len(s.RequestsFor(route)); got != n {
+		t.Errorf("dhltest: %s received %d requests, want %d", route, got, n)
+	}
+}
+
+// Reset forgets recorded requests, injected errors and custom responses.
+func (s *Server) Reset() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.responses = defaultResponses()
+	s.injected = make(map[Route][]Response)
+	s.requests = nil
+}
+
+func (s *Server) handle(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	route, ok := matchRoute(r)
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.mu.Lock()
+	s.requests = append(s.requests, Request{
+		Route:  route,
+		Method: r.Method,
+		Path:   r.URL.Path,
+		Header: r.Header.Clone(),
+		Body:   body,
+	})
+	if route != RouteAuth && r.Header.Get("Authorization") != "Bearer "+AccessToken {
+		s.mu.Unlock()
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"title": "Unauthorized", "detail": "invalid or missing access token"})
+		return
+	}
+	resp := s.responses[route]
+	if queued := s.injected[route]; len(queued) > 0 {
+		resp = queued[0]
+		s.injected[route] = queued[1:]
+	}
+	s.mu.Unlock()
+
+	for key, values := range resp.Header {
+		for _, value := range values {
+			w.Header().Add(key, value)
+		}
+	}
+	w.WriteHeader(resp.Status)
+	w.Write(resp.Body)
+}
+
+func matchRoute(r *http.Request) (Route, bool) {
+	path := strings.TrimPrefix(r.URL.Path, basePath)
+
+	switch {
+	case r.Method == http.MethodPost && path == "/oauth/accesstoken":
+		return RouteAuth, true
+	case r.Method == http.MethodPost && path == "/shipping/v1/orders":
+		return RouteCreateOrder, true
+	case r.Method == http.MethodGet && strings.HasPrefix(path, "/shipping/v1/items/") && strings.HasSuffix(path, "/label"):
+		return RouteItemLabel, true
+	}
+	return "", false
+}
+
+func defaultResponses() map[Route]Response {
+	return map[Route]Response{
+		RouteAuth: jsonResponse(http.StatusOK, map[string]interface{}{
+			"access_token": AccessToken,
+			"token_type":   "Bearer",
+			"expires_in":   3600,
+		}),
+		RouteCreateOrder: jsonResponse(http.StatusCreated, map[string]interface{}{
+			"orderId": "dhltest-order-1",
+			"shipments": []map[string]interface{}{{
+				"awb": "dhltest-awb-1",
+				"items": []map[string]interface{}{{
+					"id":      "dhltest-item-1",
+					"barcode": "RR123456785DE",
+				}},
+			}},
+		}),
+		RouteItemLabel: {
+			Status: http.StatusOK,
+			Header: http.Header{"Content-Type": []string{"application/pdf"}},
+			Body:   []byte("%PDF-1.4\n% dhltest label\n%%EOF\n"),
+		},
+	}
+}
+
+func jsonResponse(status int, v interface{}) Response {
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(v); err != nil {
+		panic(fmt.Sprintf("dhltest: encoding canned response: %v", err))
+	}
+	return Response{
+		Status: status,
+		Header: http.Header{"Content-Type": []string{"application/json"}},
+		Body:   buf.Bytes(),
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const getProofOfDeliveryPath = "/tracking/v1/trackings/%s/proofofdelivery"
+
+type Document struct {
+	Data        []byte
+	ContentType string
+}
+
+// GetProofOfDelivery downloads the signed proof-of-delivery document of a
+// delivered shipment. It returns an error matching ErrNotFound while DHL has
+// no POD for the tracking number yet.
+func (c *DHLClient) GetProofOfDelivery(ctx context.Context, trackingNumber string) (_ *Document, err error) {
+	defer wrapOp(&err, opGetPOD)
+
+	req, err := http.NewRequestWithContext(withOpKind(ctx, opKindLabel), http.MethodGet, c.url(getProofOfDeliveryPath, trackingNumber), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	if contentType == "" || contentType == "application/octet-stream" {
+		contentType = http.DetectContentType(data)
+	}
+
+	return &Document{Data: data, ContentType: contentType}, nil
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const maxErrorBodySize = 512
+
+var ErrNotFound = errors.New("not found")
+
+// ErrQuotaExceeded matches API errors reporting that the account's contract
+// volume is used up. Retrying cannot succeed until the contract is topped up.
+var ErrQuotaExceeded = errors.New("contract quota exceeded")
+
+// quotaErrorCodes are the envelope error codes DHL uses for exhausted
+// contract volumes.
+var quotaErrorCodes = map[string]bool{
+	"CONTRACT_VOLUME_EXCEEDED": true,
+	"QUOTA_EXCEEDED":           true,
+}
+
+// ErrAlreadyManifested matches API errors rejecting the cancellation of an
+// order that was already manifested; use VoidShipment for its items instead.
+var ErrAlreadyManifested = errors.New("order already manifested")
+
+// manifestedErrorCodes are the envelope error codes DHL uses when an order
+// can no longer be deleted because it was manifested.
+var manifestedErrorCodes = map[string]bool{
+	"ALREADY_MANIFESTED":       true,
+	"ORDER_ALREADY_MANIFESTED": true,
+}
+
+// ErrInvalidOrder wraps the validation failures of typed orders.
+var ErrInvalidOrder = errors.New("invalid order")
+
+// ErrInvalidLabel is returned by clients created WithLabelVerification when
+// a downloaded label is not a document of the expected format.
+var ErrInvalidLabel = errors.New("invalid label content")
+
+// ErrServiceUnavailable matches 503 responses that carry a non-JSON body,
+// which is how DHL answers during maintenance windows.
+var ErrServiceUnavailable = errors.New("service unavailable")
+
+// APIError is returned when DHL answers with an unexpected status code. The
+// problem-details fields are filled in when the body is DHL's JSON error
+// envelope; Body always holds the raw response.
+type APIError struct {
+	StatusCode int
+	Type       string
+	Title      string
+	Detail     string
+	Instance   string
+	Errors     []APIErrorDetail
+	Body       string
+
+	// Maintenance is set for 503 responses whose body is not DHL's JSON
+	// envelope, typically an HTML maintenance page.
+	Maintenance bool
+}
+
+type APIErrorDetail struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+	Field   string `json:"field,omitempty"`
+}
+
+type apiErrorEnvelope struct {
+	Type     string           `json:"type"`
+	Title    string           `json:"title"`
+	Status   int              `json:"status"`
+	Detail   string           `json:"detail"`
+	Instance string           `json:"instance"`
+	Errors   []APIErrorDetail `json:"errors"`
+	Messages []string         `json:"messages"`
+}
+
+// ParseAPIError decodes a DHL error response. Bodies that are not DHL's JSON
+// envelope are kept verbatim in Body.
+func ParseAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{StatusCode: statusCode, Body: string(body)}
+
+	var envelope apiErrorEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		apiErr.Maintenance = statusCode == http.StatusServiceUnavailable
+		return apiErr
+	}
+
+	apiErr.Type = envelope.Type
+	apiErr.Title = envelope.Title
+	apiErr.Detail = envelope.Detail
+	apiErr.Instance = envelope.Instance
+	apiErr.Errors = envelope.Errors
+	for _, message := range envelope.Messages {
+		apiErr.Errors = append(apiErr.Errors, APIErrorDetail{Message: message})
+	}
+	return apiErr
+}
+
+func newAPIError(resp *http.Response) *APIError {
+	body, _ := io.ReadAll(resp.Body)
+	return ParseAPIError(resp.StatusCode, body)
+}
+
+func (e *APIError) Error() string {
+	msg := fmt.Sprintf("unexpected status code: %d", e.StatusCode)
+	if e.Maintenance {
+		return msg + ", DHL service unavailable (likely a maintenance window)"
+	}
+
+	var parts []string
+	if e.Title != "" {
+		parts = append(parts, e.Title)
+	}
+	if e.Detail != "" {
+		parts = append(parts, e.Detail)
+	}
+	for _, detail := range e.Errors {
+		part := detail.Message
+		if detail.Field != "" {
+			part = detail.Field + ": " + part
+		}
+		if detail.Code != "" {
+			part = "[" + detail.Code + "] " + part
+		}
+		parts = append(parts, part)
+	}
+
+	switch {
+	case len(parts) > 0:
+		return msg + ", " + strings.Join(parts, "; ")
+	case e.Body != "":
+		return msg + ", body: " + e.Body
+	}
+	return msg
+}
+
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrServiceUnavailable:
+		return e.Maintenance
+	case ErrQuotaExceeded:
+		return e.hasCode(quotaErrorCodes)
+	case ErrAlreadyManifested:
+		// Without an envelope, a conflict is the only reason DHL refuses a delete.
+		return e.hasCode(manifestedErrorCodes) || (e.StatusCode == http.StatusConflict && len(e.Errors) == 0)
+	}
+	return false
+}
+
+func (e *APIError) hasCode(codes map[string]bool) bool {
+	for _, detail := range e.Errors {
+		if codes[strings.ToUpper(detail.Code)] {
+			return true
+		}
+	}
+	return false
+}
+
+type AuthError struct {
+	StatusCode      int
+	Body            string
+	WWWAuthenticate string
+}
+
+func (e *AuthError) Error() string {
+	msg := fmt.Sprintf("authentication failed: status %d", e.StatusCode)
+	if e.WWWAuthenticate != "" {
+		msg += fmt.Sprintf(", www-authenticate: %s", e.WWWAuthenticate)
+	}
+	if e.Body != "" {
+		msg += fmt.Sprintf(", body: %s", e.Body)
+	}
+	return msg
+}
+
+var secretFieldPattern = regexp.MustCompile(`(?i)("?(?:access_token|refresh_token|client_secret|password)"?\s*[:=]\s*)("[^"]*"|[^&\s,}]+)`)
+
+func redactSecrets(body string, secrets ...string) string {
+	for _, secret := range secrets {
+		if secret != "" {
+			body = strings.ReplaceAll(body, secret, "[REDACTED]")
+		}
+	}
+	return secretFieldPattern.ReplaceAllString(body, `${1}"[REDACTED]"`)
+}
+
+func truncateBody(body []byte, limit int) string {
+	if len(body) <= limit {
+		return string(body)
+	}
+	return string(body[:limit]) + "...(truncated)"
+}
+
+const (
+	opAuth          = "auth"
+	opCreateOrder   = "create_order"
+	opCreateAndSave = "create_and_save_label"
+	opCreateLabel   = "create_label"
+	opCreateOrders  = "create_orders"
+	opCheckService  = "check_serviceability"
+	opDeleteOrder   = "delete_order"
+	opDeleteOrders  = "delete_orders"
+	opCreateWebhook = "create_webhook_subscription"
+	opDeleteWebhook = "delete_webhook_subscription"
+	opPreviewOrder  = "preview_order"
+	opVoidShipment  = "void_shipment"
+	opGetOrder      = "get_order"
+	opPollLocation  = "poll_location"
+	opGetItem       = "get_item"
+	opGetLabel      = "get_label"
+	opOrderPacket   = "generate_order_packet"
+	opDownloadZip   = "download_labels_zip"
+	opGetCustoms    = "get_customs_invoice"
+	opGetBalance    = "get_account_balance"
+	opGetSettings   = "get_account_settings"
+	opGetBatch      = "get_batch_status"
+	opGetTracking   = "get_tracking_status"
+	opGetPOD        = "get_proof_of_delivery"
+	opWaitBatch     = "wait_for_batch"
+	opListOrders    = "list_orders"
+	opListProducts  = "list_products"
+	opRenderZPL     = "render_zpl"
+	opResetSandbox  = "reset_sandbox"
+	opWatchOrder    = "watch_order"
+)
+
+type OpError struct {
+	Op  string
+	Err error
+}
+
+func (e *OpError) Error() string {
+	return e.Op + ": " + e.Err.Error()
+}
+
+func (e *OpError) Unwrap() error {
+	return e.Err
+}
+
+func wrapOp(err *error, op string) {
+	if *err == nil {
+		return
+	}
+	if opErr, ok := (*err).(*OpError); ok && opErr.Op == op {
+		return
+	}
+	*err = &OpError{Op: op, Err: *err}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+const eventBufferSize = 64
+
+type EventType string
+
+const (
+	RequestStarted   EventType = "request_started"
+	RequestCompleted EventType = "request_completed"
+	RetryScheduled   EventType = "retry_scheduled"
+	TokenRefreshed   EventType = "token_refreshed"
+)
+
+// Event describes something the client did. Method and URL are set for
+// request and retry events, StatusCode and Err for completed requests,
+// Attempt and Delay for scheduled retries and ExpiresAt for token refreshes.
+type Event struct {
+	Type       EventType
+	Time       time.Time
+	Method     string
+	URL        string
+	StatusCode int
+	Err        error
+	Attempt    int
+	Delay      time.Duration
+	ExpiresAt  time.Time
+}
+
+// Events returns the channel the client publishes its events to. Publishing
+// never blocks: events are dropped while the channel's buffer is full, so
+// consume it promptly if every event matters.
+func (c *DHLClient) Events() <-chan Event {
+	return c.events
+}
+
+func (c *DHLClient) publish(e Event) {
+	e.Time = c.now()
+	select {
+	case c.events <- e:
+	default:
+	}
+}
+
+func (c *DHLClient) publishExchange(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		c.publish(Event{Type: RequestStarted, Method: req.Method, URL: req.URL.String()})
+
+		resp, err := next(req)
+
+		completed := Event{Type: RequestCompleted, Method: req.Method, URL: req.URL.String(), Err: err}
+		if resp != nil {
+			completed.StatusCode = resp.StatusCode
+		}
+		c.publish(completed)
+		return resp, err
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// LabelFileInfo describes a label for the placeholders of a label path
+// template.
+type LabelFileInfo struct {
+	Date           time.Time
+	Reference      string
+	TrackingNumber string
+	ItemID         string
+}
+
+var labelPathPlaceholders = map[string]bool{
+	"date":           true,
+	"reference":      true,
+	"trackingNumber": true,
+	"itemID":         true,
+	"ext":            true,
+}
+
+// WithLabelPathTemplate sets where the client saves labels below the target
+// directory, e.g. "{date}/{reference}.{ext}". The placeholders are date
+// (YYYY-MM-DD), reference, trackingNumber, itemID and ext (the label's file
+// extension without the dot). NewDHLClient rejects malformed templates.
+func WithLabelPathTemplate(tmpl string) Option {
+	return func(c *DHLClient) {
+		c.labelPathTemplate = tmpl
+	}
+}
+
+func validateLabelPathTemplate(tmpl string) error {
+	if tmpl == "" {
+		return errors.New("template is empty")
+	}
+	if path.IsAbs(tmpl) || filepath.IsAbs(tmpl) {
+		return fmt.Errorf("template %q must be relative", tmpl)
+	}
+
+	seen := make(map[string]bool)
+	rest := tmpl
+	for {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			break
+		}
+		if rest[open] == '}' {
+			return fmt.Errorf("template %q has an unmatched '}'", tmpl)
+		}
+		end := strings.IndexAny(rest[open+1:], "{}")
+		if end < 0 || rest[open+1+end] != '}' {
+			return fmt.Errorf("template %q has an unclosed '{'", tmpl)
+		}
+		name := rest[open+1 : open+1+end]
+		if !labelPathPlaceholders[name] {
+			return fmt.Errorf("template %q uses unknown placeholder {%s}", tmpl, name)
+		}
+		seen[name] = true
+		rest = rest[open+1+end+1:]
+	}
+
+	for _, segment := range strings.Split(tmpl, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return fmt.Errorf("template %q has an empty or relative path segment", tmpl)
+		}
+	}
+	if !seen["reference"] && !seen["trackingNumber"] && !seen["itemID"] {
+		return fmt.Errorf("template %q must contain {reference}, {trackingNumber} or {itemID} so labels do not overwrite each other", tmpl)
+	}
+	return nil
+}
+
+func renderLabelPath(tmpl string, info LabelFileInfo, label *Label) string {
+	date := "unknown"
+	if !info.Date.IsZero() {
+		date = info.Date.Format("2006-01-02")
+	}
+
+	r := strings.NewReplacer(
+		"{date}", date,
+		"{reference}", labelPathValue(info.Reference),
+		"{trackingNumber}", labelPathValue(info.TrackingNumber),
+		"{itemID}", labelPathValue(info.ItemID),
+		"{ext}", strings.TrimPrefix(label.Extension(), "."),
+	)
+	return filepath.FromSlash(r.Replace(tmpl))
+}
+
+// labelPathValue keeps placeholder values from adding or escaping
+// directories.
+func labelPathValue(value string) string {
+	value = strings.NewReplacer("/", "_", "\\", "_", "..", "_").Replace(value)
+	if value == "" {
+		return "unknown"
+	}
+	return value
+}
+
+// SaveLabel saves the label below dir at the path rendered from the
+// client's label path template. Without a template it behaves like the
+// package-level SaveLabel, naming the file after the tracking number, item
+// ID or reference, whichever is set first.
+func (c *DHLClient) SaveLabel(dir string, info LabelFileInfo, label *Label) (string, error) {
+	if c.labelPathTemplate == "" {
+		name := info.TrackingNumber
+		for _, candidate := range []string{info.ItemID, info.Reference} {
+			if name == "" {
+				name = candidate
+			}
+		}
+		return SaveLabel(dir, labelPathValue(name), label)
+	}
+
+	if info.Date.IsZero() {
+		info.Date = c.now()
+	}
+
+	return writeLabelFile(filepath.Join(dir, renderLabelPath(c.labelPathTemplate, info, label)), label)
+}
@@ -0,0 +1,433 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+type LabelFormat string
+
+const (
+	LabelFormatPDF LabelFormat = "PDF"
+	LabelFormatPNG LabelFormat = "PNG"
+	LabelFormatZPL LabelFormat = "ZPL"
+)
+
+func (f LabelFormat) Validate() error {
+	switch f {
+	case LabelFormatPDF, LabelFormatPNG, LabelFormatZPL:
+		return nil
+	}
+	return fmt.Errorf("unsupported label format %q (want %s, %s or %s)", string(f), LabelFormatPDF, LabelFormatPNG, LabelFormatZPL)
+}
+
+func (f LabelFormat) Extension() string {
+	switch f {
+	case LabelFormatPDF:
+		return ".pdf"
+	case LabelFormatPNG:
+		return ".png"
+	case LabelFormatZPL:
+		return ".zpl"
+	}
+	return ".bin"
+}
+
+func (f LabelFormat) mediaType() string {
+	switch f {
+	case LabelFormatPDF:
+		return "application/pdf"
+	case LabelFormatPNG:
+		return "image/png"
+	case LabelFormatZPL:
+		return "application/zpl"
+	}
+	return "*/*"
+}
+
+type Orientation string
+
+const (
+	OrientationPortrait  Orientation = "PORTRAIT"
+	OrientationLandscape Orientation = "LANDSCAPE"
+)
+
+// LabelOptions tune the label document DHL renders. Zero values leave the
+// choice to DHL: the account's default format, portrait orientation and the
+// account's locale. Language is an ISO 639-1 code. DPI is the resolution of
+// ZPL labels, 203 or 300; ZPL is requested at 203 DPI unless set.
+type LabelOptions struct {
+	Format      LabelFormat
+	Orientation Orientation
+	Language    string
+	DPI         int
+}
+
+const defaultLabelDPI = 203
+
+func (o LabelOptions) validate() error {
+	if o.Format != "" {
+		if err := o.Format.Validate(); err != nil {
+			return err
+		}
+	}
+	switch o.Orientation {
+	case "", OrientationPortrait, OrientationLandscape:
+	default:
+		return fmt.Errorf("unsupported orientation %q", string(o.Orientation))
+	}
+	if o.Language != "" {
+		if _, err := normalizeLanguage(o.Language); err != nil {
+			return err
+		}
+	}
+	switch o.DPI {
+	case 0, 203, 300:
+	default:
+		return fmt.Errorf("unsupported label resolution %d dpi (want 203 or 300)", o.DPI)
+	}
+	return nil
+}
+
+func (o LabelOptions) query() url.Values {
+	q := url.Values{}
+	if o.Orientation == OrientationLandscape {
+		q.Set("orientation", string(o.Orientation))
+	}
+	if o.Language != "" {
+		language, _ := normalizeLanguage(o.Language)
+		q.Set("language", language)
+	}
+	switch {
+	case o.DPI != 0:
+		q.Set("dpi", strconv.Itoa(o.DPI))
+	case o.Format == LabelFormatZPL:
+		q.Set("dpi", strconv.Itoa(defaultLabelDPI))
+	}
+	return q
+}
+
+type Label struct {
+	Data        []byte
+	ContentType string
+}
+
+var (
+	pdfMagic = []byte("%PDF")
+	pngMagic = []byte("\x89PNG\r\n\x1a\n")
+	zplMagic = []byte("^XA")
+)
+
+// Format reports the label format based on the response content type,
+// falling back to sniffing the leading bytes.
+func (l *Label) Format() LabelFormat {
+	if format := l.declaredFormat(); format != "" {
+		return format
+	}
+	return l.sniffFormat()
+}
+
+func (l *Label) declaredFormat() LabelFormat {
+	mediaType, _, _ := mime.ParseMediaType(l.ContentType)
+	switch {
+	case mediaType == "application/pdf":
+		return LabelFormatPDF
+	case mediaType == "image/png":
+		return LabelFormatPNG
+	case strings.Contains(mediaType, "zpl"):
+		return LabelFormatZPL
+	}
+	return ""
+}
+
+func (l *Label) sniffFormat() LabelFormat {
+	data := bytes.TrimLeft(l.Data, " \t\r\n")
+	switch {
+	case bytes.HasPrefix(data, pdfMagic):
+		return LabelFormatPDF
+	case bytes.HasPrefix(data, pngMagic):
+		return LabelFormatPNG
+	case bytes.HasPrefix(data, zplMagic):
+		return LabelFormatZPL
+	}
+	return ""
+}
+
+func (l *Label) Extension() string {
+	return l.Format().Extension()
+}
+
+var (
+	pdfPagePattern  = regexp.MustCompile(`/Type\s*/Page[^s]`)
+	pdfCountPattern = regexp.MustCompile(`/Type\s*/Pages\b[^>]*?/Count\s+(\d+)|/Count\s+(\d+)[^>]*?/Type\s*/Pages\b`)
+)
+
+// PageCount reports the number of pages of the label: the page objects of a
+// PDF, the ^XA label blocks of ZPL, and 1 for PNG.
+func (l *Label) PageCount() (int, error) {
+	switch format := l.Format(); format {
+	case LabelFormatPNG:
+		return 1, nil
+	case LabelFormatZPL:
+		if n := bytes.Count(l.Data, zplMagic); n > 0 {
+			return n, nil
+		}
+		return 0, errors.New("ZPL label contains no ^XA block")
+	case LabelFormatPDF:
+		if n := len(pdfPagePattern.FindAll(l.Data, -1)); n > 0 {
+			return n, nil
+		}
+		// Page objects may sit in compressed object streams; the page tree
+		// root usually does not.
+		if m := pdfCountPattern.FindSubmatch(l.Data); m != nil {
+			count := m[1]
+			if len(count) == 0 {
+				count = m[2]
+			}
+			if n, err := strconv.Atoi(string(count)); err == nil && n > 0 {
+				return n, nil
+			}
+		}
+		return 0, errors.New("cannot determine the page count of the PDF label")
+	default:
+		return 0, fmt.Errorf("cannot determine the page count of a label with content type %q", l.ContentType)
+	}
+}
+
+// WithFallbackLabelFormat makes label downloads that request a format the
+// product does not offer retry once with format. Label.Format reports which
+// format was returned.
+func WithFallbackLabelFormat(format LabelFormat) Option {
+	return func(c *DHLClient) {
+		c.fallbackFormat = format
+	}
+}
+
+// isUnsupportedFormat reports whether DHL refused a label request because of
+// its format: 406 Not Acceptable, or an error code naming the format.
+func isUnsupportedFormat(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	if apiErr.StatusCode == http.StatusNotAcceptable || apiErr.StatusCode == http.StatusUnsupportedMediaType {
+		return true
+	}
+	for _, detail := range apiErr.Errors {
+		if strings.Contains(strings.ToUpper(detail.Code), "FORMAT") {
+			return true
+		}
+	}
+	return false
+}
+
+// WithLabelVerification makes the client check the leading bytes of every
+// downloaded label against the requested format, or the declared content
+// type when no format was requested. Mismatches, such as an HTML error page
+// served with status 200, fail with ErrInvalidLabel.
+func WithLabelVerification() Option {
+	return func(c *DHLClient) {
+		c.verifyLabels = true
+	}
+}
+
+func (c *DHLClient) verifyLabel(label *Label, want LabelFormat) error {
+	if !c.verifyLabels {
+		return nil
+	}
+	if want == "" {
+		want = label.declaredFormat()
+	}
+
+	got := label.sniffFormat()
+	switch {
+	case got == "":
+		return fmt.Errorf("%w: content starts with %q", ErrInvalidLabel, truncateBody(label.Data, 16))
+	case want != "" && got != want:
+		return fmt.Errorf("%w: expected %s, got %s", ErrInvalidLabel, want, got)
+	}
+	return nil
+}
+
+func (c *DHLClient) GetLabel(ctx context.Context, itemID string, opts LabelOptions) (_ *Label, err error) {
+	defer wrapOp(&err, opGetLabel)
+
+	return c.fetchLabel(ctx, itemID, opts)
+}
+
+// GetShipmentLabel downloads the label of one shipment of an order, by the
+// shipment's AWB. Use it for split orders, whose shipments are labelled
+// separately; GetLabel still returns the label of a single item.
+func (c *DHLClient) GetShipmentLabel(ctx context.Context, awb string, opts LabelOptions) (_ *Label, err error) {
+	defer wrapOp(&err, opGetLabel)
+
+	return c.fetchLabelFrom(ctx, c.url(getAWBLabelPath, url.PathEscape(awb)), opts)
+}
+
+// GetLabelByTrackingNumber re-downloads the label of an item from its
+// tracking number, looking up the item by its barcode first. Tracking
+// numbers without an item fail with ErrNotFound.
+func (c *DHLClient) GetLabelByTrackingNumber(ctx context.Context, trackingNumber string, format LabelFormat) (_ *Label, err error) {
+	defer wrapOp(&err, opGetLabel)
+
+	barcode := strings.ToUpper(strings.ReplaceAll(trackingNumber, " ", ""))
+	item, err := c.findItemByBarcode(ctx, barcode)
+	if err != nil {
+		return nil, fmt.Errorf("looking up tracking number %s: %w", trackingNumber, err)
+	}
+
+	return c.fetchLabel(ctx, url.PathEscape(item.ID), LabelOptions{Format: format})
+}
+
+func (c *DHLClient) GetItemLabelImage(ctx context.Context, itemID string) (_ image.Image, err error) {
+	defer wrapOp(&err, opGetLabel)
+
+	label, err := c.fetchLabel(ctx, itemID, LabelOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	switch format := label.Format(); format {
+	case LabelFormatPNG:
+		img, err := png.Decode(bytes.NewReader(label.Data))
+		if err != nil {
+			return nil, fmt.Errorf("decoding PNG label: %w", err)
+		}
+		return img, nil
+	case LabelFormatPDF, LabelFormatZPL:
+		return nil, fmt.Errorf("label for item %s is %s, not an image; use GetItemLabel for the raw bytes", itemID, format)
+	default:
+		return nil, fmt.Errorf("label for item %s has unrecognized content type %q; use GetItemLabel for the raw bytes", itemID, label.ContentType)
+	}
+}
+
+// SaveLabel writes the label to dir as name plus the extension matching the
+// label format and returns the path of the written file.
+func SaveLabel(dir, name string, label *Label) (string, error) {
+	return writeLabelFile(filepath.Join(dir, name+label.Extension()), label)
+}
+
+func writeLabelFile(path string, label *Label) (string, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("creating label directory: %w", err)
+	}
+
+	if err := os.WriteFile(path, label.Data, 0644); err != nil {
+		return "", fmt.Errorf("writing label: %w", err)
+	}
+
+	return path, nil
+}
+
+// CreateAndSaveLabel creates the order, downloads the label of its first item
+// and saves it to dir with the client's SaveLabel.
+func (c *DHLClient) CreateAndSaveLabel(ctx context.Context, order Order, dir string) (_ string, err error) {
+	defer wrapOp(&err, opCreateAndSave)
+
+	createOrderResp, err := c.createTypedOrder(ctx, order)
+	if err != nil {
+		return "", err
+	}
+
+	info := LabelFileInfo{Reference: order.Reference, ItemID: createOrderResp.OrderID}
+	if items := createOrderResp.items(); len(items) > 0 {
+		info.ItemID = items[0].ID
+		info.TrackingNumber = items[0].Barcode
+	}
+
+	label, err := c.fetchLabel(ctx, info.ItemID, LabelOptions{})
+	if err != nil {
+		return "", fmt.Errorf("order %s created but label download failed: %w", createOrderResp.OrderID, err)
+	}
+
+	return c.SaveLabel(dir, info, label)
+}
+
+const createLabelPath = "/shipping/v1/labels"
+
+type CreatedLabel struct {
+	ItemID         string
+	TrackingNumber string
+	Label          *Label
+}
+
+type createLabelResponse struct {
+	ItemID      string `json:"itemId"`
+	Barcode     string `json:"barcode"`
+	Label       []byte `json:"label"`
+	ContentType string `json:"contentType"`
+}
+
+// CreateLabel labels a single parcel straight from the shipment data,
+// skipping the order→item round trip. Only products with
+// ProductCharacteristics.DirectLabel support it; use CreateOrder and
+// GetLabel for the others.
+func (c *DHLClient) CreateLabel(ctx context.Context, order Order) (_ *CreatedLabel, err error) {
+	defer wrapOp(&err, opCreateLabel)
+
+	order, err = c.applyDefaults(order).normalize(c.now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOrder, err)
+	}
+	if product, known := ProductInfo(order.ProductCode); known && !product.DirectLabel {
+		return nil, fmt.Errorf("product %s does not support label-only creation", order.ProductCode)
+	}
+	if len(order.Items) > 1 {
+		return nil, fmt.Errorf("label-only creation takes a single parcel, got %d items", len(order.Items))
+	}
+
+	jsonData, err := c.marshal(order)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling order data: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(withOpKind(ctx, opKindLabel), http.MethodPost, c.url(createLabelPath), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", c.contentType)
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, jsonData)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return nil, newAPIError(resp)
+	}
+
+	var labelResp createLabelResponse
+	if err := json.NewDecoder(resp.Body).Decode(&labelResp); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	label := &Label{Data: labelResp.Label, ContentType: labelResp.ContentType}
+	if err := c.verifyLabel(label, ""); err != nil {
+		return nil, err
+	}
+
+	return &CreatedLabel{
+		ItemID:         labelResp.ItemID,
+		TrackingNumber: labelResp.Barcode,
+		Label:          label,
+	}, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"strings"
+)
+
+// LabelMetadata are the print hints DHL sends alongside some labels.
+// Dimensions are in millimeters.
+type LabelMetadata struct {
+	Format   LabelFormat `json:"labelFormat"`
+	DPI      int         `json:"dpi,omitempty"`
+	WidthMM  float64     `json:"widthMm,omitempty"`
+	HeightMM float64     `json:"heightMm,omitempty"`
+}
+
+// GetItemLabelWithSidecar downloads an item's label together with DHL's
+// metadata sidecar, which DHL sends as the JSON part of a multipart
+// response. metadata is nil when DHL answers with the bare document.
+func (c *DHLClient) GetItemLabelWithSidecar(ctx context.Context, itemID string) (label *Label, metadata *LabelMetadata, err error) {
+	defer wrapOp(&err, opGetLabel)
+
+	ctx = withOpKind(ctx, opKindLabel)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(getItemLabelPath, itemID), nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, nil, err
+	}
+	req.Header.Add("Accept", "multipart/mixed, */*;q=0.8")
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, nil, newAPIError(resp)
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	mediaType, params, _ := mime.ParseMediaType(contentType)
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		data, err := io.ReadAll(resp.Body)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading response body: %w", err)
+		}
+		label = &Label{Data: data, ContentType: contentType}
+	} else {
+		label, metadata, err = readLabelParts(multipart.NewReader(resp.Body, params["boundary"]))
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading response body: %w", err)
+		}
+	}
+
+	var want LabelFormat
+	if metadata != nil {
+		want = metadata.Format
+	}
+	if err := c.verifyLabel(label, want); err != nil {
+		return nil, nil, err
+	}
+
+	return label, metadata, nil
+}
+
+func readLabelParts(r *multipart.Reader) (*Label, *LabelMetadata, error) {
+	var label *Label
+	var metadata *LabelMetadata
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		contentType := part.Header.Get("Content-Type")
+		mediaType, _, _ := mime.ParseMediaType(contentType)
+		if mediaType == "application/json" && metadata == nil {
+			metadata = &LabelMetadata{}
+			if err := json.NewDecoder(part).Decode(metadata); err != nil {
+				return nil, nil, fmt.Errorf("decoding label metadata: %w", err)
+			}
+			continue
+		}
+		if label == nil {
+			data, err := io.ReadAll(part)
+			if err != nil {
+				return nil, nil, err
+			}
+			label = &Label{Data: data, ContentType: contentType}
+		}
+	}
+
+	if label == nil {
+		return nil, nil, errors.New("multipart response has no label document")
+	}
+	return label, metadata, nil
+}
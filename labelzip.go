@@ -0,0 +1,82 @@
+package main
+
+import (
+	"archive/zip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+const labelDownloadConcurrency = 4
+
+type labelResult struct {
+	label *Label
+	err   error
+}
+
+// DownloadLabelsZip fetches the labels of itemIDs concurrently and streams
+// them into a ZIP archive written to w, one entry per item named by item id
+// and extension, with path separators and ".." in ids replaced by "_" so no
+// entry can point outside the extraction directory. Items whose label cannot
+// be fetched are left out of the archive and reported in the returned error.
+func (c *DHLClient) DownloadLabelsZip(ctx context.Context, itemIDs []string, w io.Writer) (err error) {
+	defer wrapOp(&err, opDownloadZip)
+
+	ctx, cancel := context.WithCancel(ctx)
+
+	results := make([]chan labelResult, len(itemIDs))
+	for i := range results {
+		results[i] = make(chan labelResult, 1)
+	}
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, labelDownloadConcurrency)
+	for i, itemID := range itemIDs {
+		wg.Add(1)
+		go func(i int, itemID string) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				results[i] <- labelResult{err: ctx.Err()}
+				return
+			}
+			label, err := c.fetchLabel(ctx, itemID, LabelOptions{})
+			results[i] <- labelResult{label: label, err: err}
+		}(i, itemID)
+	}
+	defer func() {
+		cancel()
+		wg.Wait()
+	}()
+
+	zw := zip.NewWriter(w)
+	var failures []error
+	for i, itemID := range itemIDs {
+		result := <-results[i]
+		if result.err != nil {
+			failures = append(failures, fmt.Errorf("item %s: %w", itemID, result.err))
+			continue
+		}
+
+		entry, err := zw.Create(labelPathValue(itemID) + result.label.Extension())
+		if err != nil {
+			return fmt.Errorf("writing zip entry for item %s: %w", itemID, err)
+		}
+		if _, err := entry.Write(result.label.Data); err != nil {
+			return fmt.Errorf("writing zip entry for item %s: %w", itemID, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("finishing zip archive: %w", err)
+	}
+
+	if len(failures) > 0 {
+		return fmt.Errorf("%d of %d labels failed: %w", len(failures), len(itemIDs), errors.Join(failures...))
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"main.go/dhltest"
+)
+
+func TestDownloadLabelsZipEntryNamesStayInside(t *testing.T) {
+	srv := dhltest.NewServer()
+	defer srv.Close()
+
+	c, err := NewDHLClient("id", "secret", WithBaseURL(srv.BaseURL()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	if err := c.DownloadLabelsZip(context.Background(), []string{"item-1", `..\..\evil`, "../../etc/passwd"}, &buf); err != nil {
+		t.Fatalf("DownloadLabelsZip: %v", err)
+	}
+
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range zr.File {
+		if strings.ContainsAny(f.Name, `/\`) || strings.Contains(f.Name, "..") {
+			t.Errorf("unsafe entry name %q", f.Name)
+		}
+	}
+}
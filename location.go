@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	locationPollMinInterval = time.Second
+	locationPollMaxInterval = 30 * time.Second
+)
+
+// PollLocation follows the Location URL DHL returns with a 202 Accepted for
+// an asynchronously created order until the order is ready, and returns it.
+// CreateOrder does this itself; PollLocation is for callers that stored the
+// URL, e.g. across a restart. Relative URLs are resolved against the base
+// URL, and URLs on other hosts or schemes are refused so the token is never
+// sent elsewhere or in plaintext.
+func (c *DHLClient) PollLocation(ctx context.Context, location string) (_ *CreateOrderResponse, err error) {
+	defer wrapOp(&err, opPollLocation)
+
+	base, err := url.Parse(c.baseURL + "/")
+	if err != nil {
+		return nil, fmt.Errorf("parsing base URL: %w", err)
+	}
+	return c.pollLocation(ctx, base, location)
+}
+
+func (c *DHLClient) pollLocation(ctx context.Context, base *url.URL, location string) (*CreateOrderResponse, error) {
+	ref, err := url.Parse(location)
+	if err != nil {
+		return nil, fmt.Errorf("parsing location: %w", err)
+	}
+	target := base.ResolveReference(ref)
+	if target.Host != base.Host {
+		return nil, fmt.Errorf("location host %q differs from the API host %q", target.Host, base.Host)
+	}
+	if target.Scheme != base.Scheme {
+		return nil, fmt.Errorf("location scheme %q differs from the API scheme %q", target.Scheme, base.Scheme)
+	}
+
+	interval := locationPollMinInterval
+	for {
+		createOrderResp, retryAfter, err := c.getLocation(ctx, target.String())
+		if err != nil || createOrderResp != nil {
+			return createOrderResp, err
+		}
+
+		delay := interval
+		if retryAfter > 0 {
+			delay = retryAfter
+		}
+		if err := c.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+		if interval *= 2; interval > locationPollMaxInterval {
+			interval = locationPollMaxInterval
+		}
+	}
+}
+
+// getLocation fetches the resource once. A nil response without error means
+// DHL is still processing it; retryAfter is DHL's hint when to ask again.
+func (c *DHLClient) getLocation(ctx context.Context, location string) (_ *CreateOrderResponse, retryAfter time.Duration, err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, location, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, 0, err
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+	case http.StatusAccepted:
+		retryAfter, _ = parseRetryAfter(resp.Header.Get("Retry-After"), c.now())
+		return nil, retryAfter, nil
+	default:
+		return nil, 0, newAPIError(resp)
+	}
+
+	var createOrderResp CreateOrderResponse
+	if err := json.NewDecoder(resp.Body).Decode(&createOrderResp); err != nil {
+		return nil, 0, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return &createOrderResp, 0, nil
+}
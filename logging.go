@@ -0,0 +1,120 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+const defaultMaxLoggedBodySize = 4096
+
+// WithLogger enables debug logging of requests and responses. Authorization
+// headers are never logged, and tokens and secrets in bodies are redacted.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *DHLClient) {
+		c.logger = logger
+	}
+}
+
+// WithMaxLoggedBodySize truncates logged request and response bodies to n
+// bytes. Binary bodies such as PDF or image labels are never logged.
+func WithMaxLoggedBodySize(n int) Option {
+	return func(c *DHLClient) {
+		c.maxLoggedBody = n
+	}
+}
+
+func (c *DHLClient) logRequest(req *http.Request, body []byte) {
+	if c.logger == nil {
+		return
+	}
+	c.logger.Debug("dhl request",
+		"method", req.Method,
+		"url", req.URL.String(),
+		"body", c.loggableRequestBody(req, body),
+	)
+}
+
+func (c *DHLClient) loggableRequestBody(req *http.Request, body []byte) string {
+	if encoding := req.Header.Get("Content-Encoding"); encoding != "" && len(body) > 0 {
+		return fmt.Sprintf("[%s-encoded %d bytes omitted]", encoding, len(body))
+	}
+	return c.loggableBody(req.Header.Get("Content-Type"), body)
+}
+
+// logResponse logs resp and replaces its body with an in-memory copy so the
+// caller can still read it.
+func (c *DHLClient) logResponse(req *http.Request, resp *http.Response) {
+	if c.logger == nil {
+		return
+	}
+
+	contentType := resp.Header.Get("Content-Type")
+	attrs := []any{
+		"method", req.Method,
+		"url", req.URL.String(),
+		"status", resp.StatusCode,
+	}
+
+	if isBinaryContentType(contentType) {
+		attrs = append(attrs, "body", "[binary "+contentType+" omitted]")
+	} else {
+		body, err := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		resp.Body = io.NopCloser(io.MultiReader(bytes.NewReader(body), errReader{err}))
+		attrs = append(attrs, "body", c.loggableBody(contentType, body))
+	}
+
+	c.logger.Debug("dhl response", attrs...)
+}
+
+func (c *DHLClient) loggableBody(contentType string, body []byte) string {
+	if len(body) == 0 {
+		return ""
+	}
+	if isBinaryContentType(contentType) {
+		return "[binary " + contentType + " omitted]"
+	}
+
+	// Redact before truncating so a cut cannot separate a token from its key.
+	redacted := redactSecrets(string(body))
+	limit := c.maxLoggedBody
+	if limit <= 0 {
+		limit = defaultMaxLoggedBodySize
+	}
+	if len(redacted) <= limit {
+		return redacted
+	}
+	return redacted[:limit] + "…"
+}
+
+func isBinaryContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch {
+	case mediaType == "application/pdf",
+		mediaType == "application/octet-stream",
+		mediaType == "application/zip",
+		strings.HasPrefix(mediaType, "image/"),
+		strings.HasPrefix(mediaType, "multipart/"):
+		return true
+	}
+	return false
+}
+
+type errReader struct {
+	err error
+}
+
+func (r errReader) Read([]byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	return 0, io.EOF
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+
+	"main.go/dhltest"
+)
+
+func TestLoggingRedactsTokens(t *testing.T) {
+	srv := dhltest.NewServer()
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	c, err := NewDHLClient("id", "secret", WithBaseURL(srv.BaseURL()), WithLogger(logger))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := c.GetAccessToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetItemLabel(context.Background(), "item-1"); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(logs.String(), "dhl response") {
+		t.Fatalf("no responses logged:\n%s", logs.String())
+	}
+	if strings.Contains(logs.String(), dhltest.AccessToken) {
+		t.Errorf("access token logged:\n%s", logs.String())
+	}
+}
@@ -5,18 +5,28 @@ import (
 	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"strings"
+	"sync"
 	"time"
 )
 
 const (
-	sandboxBaseURL  = "https://api-sandbox.dhl.com/dpi"
-	authURL         = sandboxBaseURL + "/oauth/accesstoken"
-	createOrderURL  = sandboxBaseURL + "/shipping/v1/orders"
-	getItemLabelURL = sandboxBaseURL + "/shipping/v1/items/%s/label"
+	defaultContentType = "application/json"
+
+	sandboxBaseURL    = "https://api-sandbox.dhl.com/dpi"
+	productionBaseURL = "https://api.dhl.com/dpi"
+
+	authPath         = "/oauth/accesstoken"
+	createOrderPath  = "/shipping/v1/orders"
+	getOrderPath     = "/shipping/v1/orders/%s"
+	getItemLabelPath = "/shipping/v1/items/%s/label"
+	getAWBLabelPath  = "/shipping/v1/shipments/%s/awblabels"
 )
 
 type DHLClient struct {
@@ -24,154 +34,509 @@ type DHLClient struct {
 	ClientSecret string
 	AccessToken  string
 	HTTPClient   *http.Client
+
+	environment Environment
+	baseURL     string
+	timeout     time.Duration
+	transport   transportConfig
+	defaults    orderDefaults
+	signer      RequestSigner
+	recorder    *Recorder
+	retry       RetryPolicy
+	jitter      *jitterSource
+	indentJSON  bool
+	semaphore   chan struct{}
+	now         func() time.Time
+	sleep       func(ctx context.Context, d time.Duration) error
+	contentType string
+	redirect    func(req *http.Request, via []*http.Request) error
+
+	retryDecider func(resp *http.Response, err error) bool
+	authScheme   string
+	authRetry    *RetryPolicy
+	zplRenderURL string
+	middleware   []Middleware
+	breaker      *circuitBreaker
+
+	gzipThreshold int
+	events        chan Event
+
+	labelPathTemplate string
+	verifyLabels      bool
+	fallbackFormat    LabelFormat
+
+	defaultsMu    sync.RWMutex
+	transportWarn sync.Once
+
+	logger        *slog.Logger
+	maxLoggedBody int
+	opClients     map[opKind]*http.Client
+
+	tokenMu       sync.Mutex
+	tokenExpiry   time.Time
+	tokenScopes   []string
+	refreshMargin time.Duration
+	tenantTokens  map[credentials]cachedToken
+	tokenStore    TokenStore
+	rejectedToken string
 }
 
 type TokenResponse struct {
 	AccessToken string `json:"access_token"`
 	TokenType   string `json:"token_type"`
 	ExpiresIn   int    `json:"expires_in"`
+	Scope       string `json:"scope,omitempty"`
 }
 
 type CreateOrderResponse struct {
-	OrderID string `json:"orderId"`
+	OrderID   string     `json:"orderId"`
+	Shipments []Shipment `json:"shipments"`
 }
 
-func NewDHLClient(clientID, clientSecret string) *DHLClient {
-	return &DHLClient{
-		ClientID:     clientID,
-		ClientSecret: clientSecret,
-		HTTPClient: &http.Client{
-			Timeout: 30 * time.Second,
-		},
+// Shipment is one physical consignment of an order. Orders whose items ship
+// separately have several; each is tracked by its AWB and has its own label,
+// see GetShipmentLabel.
+type Shipment struct {
+	AWB   string         `json:"awb"`
+	Items []ShipmentItem `json:"items"`
+}
+
+func (s Shipment) TrackingNumbers() []string {
+	var numbers []string
+	for _, item := range s.Items {
+		if item.Barcode != "" {
+			numbers = append(numbers, item.Barcode)
+		}
+	}
+	return numbers
+}
+
+// ShipmentItem is one created item of a shipment; Barcode is its tracking
+// number.
+type ShipmentItem struct {
+	ID      string `json:"id"`
+	Barcode string `json:"barcode"`
+
+	// RoutingCode is the presort routing code DHL assigns the item at
+	// creation; SortCode is set for products sorted by DHL's hubs.
+	RoutingCode string `json:"routingCode,omitempty"`
+	SortCode    string `json:"sortCode,omitempty"`
+
+	// EstimatedDelivery is DHL's delivery estimate at creation, if it made
+	// one; HasEstimatedDelivery reports whether it did. GetTrackingStatus
+	// reports later estimates.
+	EstimatedDelivery    time.Time `json:"estimatedDelivery"`
+	HasEstimatedDelivery bool      `json:"-"`
+}
+
+func (i *ShipmentItem) UnmarshalJSON(data []byte) error {
+	type plain ShipmentItem
+	var raw struct {
+		plain
+		EstimatedDelivery string `json:"estimatedDelivery"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	estimate, err := parseDHLTime(raw.EstimatedDelivery)
+	if err != nil {
+		return fmt.Errorf("estimatedDelivery: %w", err)
+	}
+
+	*i = ShipmentItem(raw.plain)
+	i.EstimatedDelivery = estimate
+	i.HasEstimatedDelivery = !estimate.IsZero()
+	return nil
+}
+
+func (r *CreateOrderResponse) items() []ShipmentItem {
+	var items []ShipmentItem
+	for _, shipment := range r.Shipments {
+		items = append(items, shipment.Items...)
+	}
+	return items
+}
+
+func (r *CreateOrderResponse) TrackingNumbers() []string {
+	var numbers []string
+	for _, shipment := range r.Shipments {
+		numbers = append(numbers, shipment.TrackingNumbers()...)
+	}
+	return numbers
+}
+
+type OrderDetails struct {
+	OrderID   string      `json:"orderId"`
+	Reference string      `json:"reference,omitempty"`
+	Status    OrderStatus `json:"status"`
+	CreatedAt time.Time   `json:"createdAt"`
+	Shipments []Shipment  `json:"shipments,omitempty"`
+}
+
+func (o *OrderDetails) UnmarshalJSON(data []byte) error {
+	type plain OrderDetails
+	var raw struct {
+		plain
+		CreatedAt string `json:"createdAt"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	createdAt, err := parseDHLTime(raw.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("createdAt: %w", err)
+	}
+
+	*o = OrderDetails(raw.plain)
+	o.CreatedAt = createdAt
+	return nil
+}
+
+func NewDHLClient(clientID, clientSecret string, opts ...Option) (*DHLClient, error) {
+	c := &DHLClient{
+		ClientID:      clientID,
+		ClientSecret:  clientSecret,
+		environment:   Sandbox,
+		retry:         defaultRetryPolicy,
+		jitter:        newJitterSource(time.Now().UnixNano()),
+		now:           time.Now,
+		sleep:         sleepContext,
+		contentType:   defaultContentType,
+		redirect:      defaultCheckRedirect,
+		refreshMargin: defaultTokenRefreshMargin,
+		authScheme:    defaultAuthScheme,
+		opClients:     make(map[opKind]*http.Client),
+		events:        make(chan Event, eventBufferSize),
+	}
+
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if err := c.checkCredentials(); err != nil {
+		return nil, err
+	}
+	if c.authScheme == "" || strings.ContainsAny(c.authScheme, " \t\r\n") || strings.EqualFold(c.authScheme, "Basic") {
+		return nil, fmt.Errorf("invalid authorization scheme %q", c.authScheme)
 	}
+	if c.fallbackFormat != "" {
+		if err := c.fallbackFormat.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid fallback label format: %w", err)
+		}
+	}
+	if c.labelPathTemplate != "" {
+		if err := validateLabelPathTemplate(c.labelPathTemplate); err != nil {
+			return nil, fmt.Errorf("invalid label path template: %w", err)
+		}
+	}
+
+	if c.baseURL == "" {
+		c.baseURL = c.environment.baseURL()
+	}
+	if c.timeout == 0 {
+		c.timeout = c.environment.defaultTimeout()
+	}
+
+	if c.HTTPClient == nil {
+		c.HTTPClient = &http.Client{
+			Timeout:       c.timeout,
+			Transport:     &lazyTransport{config: c.transport},
+			CheckRedirect: c.redirect,
+		}
+	}
+
+	return c, nil
 }
 
-func (c *DHLClient) GetAccessToken(ctx context.Context) error {
-	auth := base64.StdEncoding.EncodeToString([]byte(c.ClientID + ":" + c.ClientSecret))
+func (c *DHLClient) url(path string, args ...interface{}) string {
+	return c.baseURL + fmt.Sprintf(path, args...)
+}
+
+func (c *DHLClient) FetchToken(ctx context.Context) (_ TokenResponse, err error) {
+	defer wrapOp(&err, opAuth)
+
+	return c.fetchToken(ctx, c.credentialsFor(ctx))
+}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, authURL, nil)
+func (c *DHLClient) fetchToken(ctx context.Context, creds credentials) (TokenResponse, error) {
+	// Token fetches are not attempts of the business call that triggered them.
+	ctx = withOpKind(withCallStats(ctx, nil), opKindAuth)
+
+	auth := base64.StdEncoding.EncodeToString([]byte(creds.clientID + ":" + creds.clientSecret))
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(authPath), nil)
 	if err != nil {
-		return fmt.Errorf("creating request: %w", err)
+		return TokenResponse{}, fmt.Errorf("creating request: %w", err)
 	}
 
 	req.Header.Add("Authorization", "Basic "+auth)
 	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.send(req, nil)
 	if err != nil {
-		return fmt.Errorf("sending request: %w", err)
+		return TokenResponse{}, fmt.Errorf("sending request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		body, _ := io.ReadAll(resp.Body)
+		if apiErr := ParseAPIError(resp.StatusCode, body); apiErr.Maintenance {
+			return TokenResponse{}, apiErr
+		}
+		return TokenResponse{}, &AuthError{
+			StatusCode:      resp.StatusCode,
+			Body:            truncateBody([]byte(redactSecrets(string(body), creds.clientSecret)), maxErrorBodySize),
+			WWWAuthenticate: resp.Header.Get("WWW-Authenticate"),
+		}
 	}
 
 	var tokenResp TokenResponse
 	if err := json.NewDecoder(resp.Body).Decode(&tokenResp); err != nil {
-		return fmt.Errorf("decoding response: %w", err)
+		return TokenResponse{}, fmt.Errorf("decoding response: %w", err)
 	}
 
-	c.AccessToken = tokenResp.AccessToken
-	return nil
+	return tokenResp, nil
 }
 
-func (c *DHLClient) CreateOrder(ctx context.Context, orderData map[string]interface{}) (string, error) {
-	jsonData, err := json.Marshal(orderData)
+func (c *DHLClient) GetAccessToken(ctx context.Context) (err error) {
+	defer wrapOp(&err, opAuth)
+
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	_, err = c.refreshToken(ctx)
+	return err
+}
+
+func (c *DHLClient) CreateOrder(ctx context.Context, order Order) (_ string, err error) {
+	defer wrapOp(&err, opCreateOrder)
+
+	createOrderResp, err := c.createTypedOrder(ctx, order)
 	if err != nil {
-		return "", fmt.Errorf("marshaling order data: %w", err)
+		return "", err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, createOrderURL, bytes.NewBuffer(jsonData))
+	return createOrderResp.OrderID, nil
+}
+
+func (c *DHLClient) createTypedOrder(ctx context.Context, order Order) (*CreateOrderResponse, error) {
+	order, err := c.applyDefaults(order).normalize(c.now())
 	if err != nil {
-		return "", fmt.Errorf("creating request: %w", err)
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOrder, err)
 	}
+	if err := c.checkProductScope(ctx, order.ProductCode); err != nil {
+		return nil, err
+	}
+
+	return c.createOrder(ctx, order, order.Reference)
+}
 
-	req.Header.Add("Authorization", "Bearer "+c.AccessToken)
-	req.Header.Add("Content-Type", "application/json")
+func (c *DHLClient) CreateOrderRaw(ctx context.Context, orderData map[string]interface{}) (_ string, err error) {
+	defer wrapOp(&err, opCreateOrder)
 
-	resp, err := c.HTTPClient.Do(req)
+	reference, _ := orderData["reference"].(string)
+
+	createOrderResp, err := c.createOrder(ctx, orderData, reference)
 	if err != nil {
-		return "", fmt.Errorf("sending request: %w", err)
+		return "", err
+	}
+
+	return createOrderResp.OrderID, nil
+}
+
+func (c *DHLClient) createOrder(ctx context.Context, orderData interface{}, reference string) (*CreateOrderResponse, error) {
+	jsonData, err := c.marshal(orderData)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling order data: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(createOrderPath), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", c.contentType)
+
+	resp, err := c.send(req, jsonData)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusCreated {
-		body, _ := io.ReadAll(resp.Body)
-		return "", fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, string(body))
+	var createOrderResp *CreateOrderResponse
+	switch resp.StatusCode {
+	case http.StatusCreated:
+		createOrderResp = &CreateOrderResponse{}
+		if err := json.NewDecoder(resp.Body).Decode(createOrderResp); err != nil {
+			return nil, fmt.Errorf("decoding response: %w", err)
+		}
+	case http.StatusAccepted:
+		location := resp.Header.Get("Location")
+		if location == "" {
+			return nil, errors.New("order accepted for asynchronous creation without a Location header")
+		}
+		createOrderResp, err = c.pollLocation(ctx, req.URL, location)
+		if err != nil {
+			return nil, fmt.Errorf("order accepted, polling %s: %w", location, err)
+		}
+	default:
+		return nil, newAPIError(resp)
 	}
 
-	var createOrderResp CreateOrderResponse
-	if err := json.NewDecoder(resp.Body).Decode(&createOrderResp); err != nil {
-		return "", fmt.Errorf("decoding response: %w", err)
+	if c.recorder != nil {
+		c.recorder.record(createOrderResp, reference, c.now())
 	}
 
-	return createOrderResp.OrderID, nil
+	return createOrderResp, nil
 }
 
-func (c *DHLClient) GetItemLabel(ctx context.Context, itemID string) ([]byte, error) {
-	url := fmt.Sprintf(getItemLabelURL, itemID)
+func (c *DHLClient) GetOrder(ctx context.Context, orderID string) (_ *OrderDetails, err error) {
+	defer wrapOp(&err, opGetOrder)
+
+	url := c.url(getOrderPath, orderID)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
 		return nil, fmt.Errorf("creating request: %w", err)
 	}
 
-	req.Header.Add("Authorization", "Bearer "+c.AccessToken)
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
 
-	resp, err := c.HTTPClient.Do(req)
+	resp, err := c.send(req, nil)
 	if err != nil {
 		return nil, fmt.Errorf("sending request: %w", err)
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+		return nil, newAPIError(resp)
+	}
+
+	var order OrderDetails
+	if err := json.NewDecoder(resp.Body).Decode(&order); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
 	}
 
-	label, err := io.ReadAll(resp.Body)
+	return &order, nil
+}
+
+func (c *DHLClient) GetItemLabel(ctx context.Context, itemID string) (_ []byte, err error) {
+	defer wrapOp(&err, opGetLabel)
+
+	label, err := c.fetchLabel(ctx, itemID, LabelOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	return label.Data, nil
+}
+
+func (c *DHLClient) fetchLabel(ctx context.Context, itemID string, opts LabelOptions) (*Label, error) {
+	return c.fetchLabelFrom(ctx, c.url(getItemLabelPath, itemID), opts)
+}
+
+func (c *DHLClient) fetchLabelFrom(ctx context.Context, url string, opts LabelOptions) (*Label, error) {
+	label, err := c.fetchLabelAs(ctx, url, opts)
+	if err != nil && opts.Format != "" && c.fallbackFormat != "" && opts.Format != c.fallbackFormat && isUnsupportedFormat(err) {
+		opts.Format = c.fallbackFormat
+		return c.fetchLabelAs(ctx, url, opts)
+	}
+	return label, err
+}
+
+func (c *DHLClient) fetchLabelAs(ctx context.Context, url string, opts LabelOptions) (*Label, error) {
+	ctx = withOpKind(ctx, opKindLabel)
+
+	if err := opts.validate(); err != nil {
+		return nil, fmt.Errorf("invalid label options: %w", err)
+	}
+
+	if q := opts.query().Encode(); q != "" {
+		url += "?" + q
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	if opts.Format != "" {
+		req.Header.Add("Accept", opts.Format.mediaType())
+	}
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	data, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("reading response body: %w", err)
 	}
 
+	label := &Label{Data: data, ContentType: resp.Header.Get("Content-Type")}
+	if err := c.verifyLabel(label, opts.Format); err != nil {
+		return nil, err
+	}
+
 	return label, nil
 }
 
 func main() {
-	client := NewDHLClient("your-client-id", "your-client-secret")
+	client, err := NewDHLClient("your-client-id", "your-client-secret")
+	if err != nil {
+		fmt.Printf("Error creating client: %v\n", err)
+		return
+	}
 
 	ctx := context.Background()
 
-	err := client.GetAccessToken(ctx)
+	err = client.GetAccessToken(ctx)
 	if err != nil {
 		fmt.Printf("Error getting access token: %v\n", err)
 		return
 	}
 
-	orderData := map[string]interface{}{
-		"productCode": "GPP",
-		"receiverDetails": map[string]interface{}{
-			"name": map[string]interface{}{
-				"firstName": "John",
-				"lastName":  "Doe",
+	order := Order{
+		ProductCode: ProductGPP,
+		ReceiverDetails: ReceiverDetails{
+			Name: Name{
+				FirstName: "John",
+				LastName:  "Doe",
 			},
-			"address": map[string]interface{}{
-				"street":     "Sample Street",
-				"houseNo":    "123",
-				"postalCode": "12345",
-				"city":       "Sample City",
-				"country":    "DE",
+			Address: Address{
+				Street:     "Sample Street",
+				HouseNo:    "123",
+				PostalCode: "12345",
+				City:       "Sample City",
+				Country:    "DE",
 			},
 		},
-		"shipmentDetails": map[string]interface{}{
-			"weightInGrams": 1000,
-			"length":        20,
-			"width":         15,
-			"height":        10,
+		ShipmentDetails: &ShipmentDetails{
+			WeightInGrams: 1000,
+			Length:        20,
+			Width:         15,
+			Height:        10,
 		},
 	}
 
-	orderID, err := client.CreateOrder(ctx, orderData)
+	orderID, err := client.CreateOrder(ctx, order)
 	if err != nil {
 		fmt.Printf("Error creating order: %v\n", err)
 		return
@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+type Option func(*DHLClient)
+
+type transportConfig struct {
+	dialTimeout           time.Duration
+	tlsHandshakeTimeout   time.Duration
+	responseHeaderTimeout time.Duration
+}
+
+// WithTimeout sets the overall timeout of each HTTP request, overriding the
+// environment's default.
+func WithTimeout(d time.Duration) Option {
+	return func(c *DHLClient) {
+		c.timeout = d
+	}
+}
+
+func WithDialTimeout(d time.Duration) Option {
+	return func(c *DHLClient) {
+		c.transport.dialTimeout = d
+	}
+}
+
+func WithTLSHandshakeTimeout(d time.Duration) Option {
+	return func(c *DHLClient) {
+		c.transport.tlsHandshakeTimeout = d
+	}
+}
+
+func WithResponseHeaderTimeout(d time.Duration) Option {
+	return func(c *DHLClient) {
+		c.transport.responseHeaderTimeout = d
+	}
+}
+
+// WithHTTPClient makes the client send its requests with client, for
+// example one with a custom transport. The caller's client wins over the
+// client's own settings: WithDialTimeout, WithTLSHandshakeTimeout and
+// WithResponseHeaderTimeout are ignored with a warning logged on first use,
+// and WithTimeout and WithCheckRedirect do not apply. The same holds for an
+// HTTPClient assigned after construction and for the per-operation clients
+// of WithAuthHTTPClient, WithOrderHTTPClient and WithLabelHTTPClient.
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *DHLClient) {
+		c.HTTPClient = client
+	}
+}
+
+func (tc transportConfig) isSet() bool {
+	return tc != transportConfig{}
+}
+
+// lazyTransport builds the transport from its config on the first request,
+// so clients that never send anything or whose HTTPClient is replaced
+// before use never allocate one.
+type lazyTransport struct {
+	config    transportConfig
+	once      sync.Once
+	transport *http.Transport
+}
+
+func (lt *lazyTransport) get() *http.Transport {
+	lt.once.Do(func() {
+		lt.transport = lt.config.newTransport()
+	})
+	return lt.transport
+}
+
+func (lt *lazyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	return lt.get().RoundTrip(req)
+}
+
+func (lt *lazyTransport) CloseIdleConnections() {
+	lt.get().CloseIdleConnections()
+}
+
+// warnIgnoredTransport logs once if transport options were given but client
+// was not built from them, to slog's default logger without WithLogger.
+func (c *DHLClient) warnIgnoredTransport(client *http.Client) {
+	if !c.transport.isSet() {
+		return
+	}
+	if _, ok := client.Transport.(*lazyTransport); ok {
+		return
+	}
+	c.transportWarn.Do(func() {
+		logger := c.logger
+		if logger == nil {
+			logger = slog.Default()
+		}
+		logger.Warn("dhl transport options are ignored because a custom HTTP client is used")
+	})
+}
+
+func (tc transportConfig) newTransport() *http.Transport {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+
+	if tc.dialTimeout > 0 {
+		dialer := &net.Dialer{
+			Timeout:   tc.dialTimeout,
+			KeepAlive: 30 * time.Second,
+		}
+		t.DialContext = dialer.DialContext
+	}
+	if tc.tlsHandshakeTimeout > 0 {
+		t.TLSHandshakeTimeout = tc.tlsHandshakeTimeout
+	}
+	if tc.responseHeaderTimeout > 0 {
+		t.ResponseHeaderTimeout = tc.responseHeaderTimeout
+	}
+
+	return t
+}
+
+func WithIndentedJSON(indent bool) Option {
+	return func(c *DHLClient) {
+		c.indentJSON = indent
+	}
+}
+
+func (c *DHLClient) marshal(v interface{}) ([]byte, error) {
+	if c.indentJSON {
+		return json.MarshalIndent(v, "", "  ")
+	}
+	return json.Marshal(v)
+}
+
+// WithContentType sets the media type sent with JSON request bodies, e.g.
+// "application/json; charset=utf-8" for tenants that require the charset.
+func WithContentType(contentType string) Option {
+	return func(c *DHLClient) {
+		c.contentType = contentType
+	}
+}
+
+const maxRedirects = 10
+
+// WithCheckRedirect replaces the default redirect policy of the client's
+// HTTP client.
+func WithCheckRedirect(checkRedirect func(req *http.Request, via []*http.Request) error) Option {
+	return func(c *DHLClient) {
+		c.redirect = checkRedirect
+	}
+}
+
+// defaultCheckRedirect follows up to maxRedirects redirects and re-attaches
+// the Authorization header of the original request when the redirect stays
+// on the same host, so a redirect does not silently turn into a 401.
+func defaultCheckRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return errors.New("stopped after 10 redirects")
+	}
+
+	original := via[0]
+	if req.URL.Host == original.URL.Host && req.Header.Get("Authorization") == "" {
+		if auth := original.Header.Get("Authorization"); auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+	}
+	return nil
+}
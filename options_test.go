@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"main.go/dhltest"
+)
+
+type countingTransport struct {
+	mu    sync.Mutex
+	count int
+}
+
+func (t *countingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	t.count++
+	t.mu.Unlock()
+	return http.DefaultTransport.RoundTrip(req)
+}
+
+func TestTransportPrecedence(t *testing.T) {
+	const warning = "transport options are ignored"
+
+	tests := []struct {
+		name         string
+		opts         func(custom *http.Client) []Option
+		wantCustom   bool
+		wantWarnings int
+	}{
+		{
+			name: "default client with transport options",
+			opts: func(*http.Client) []Option {
+				return []Option{WithDialTimeout(time.Second)}
+			},
+		},
+		{
+			name: "custom client without transport options",
+			opts: func(custom *http.Client) []Option {
+				return []Option{WithHTTPClient(custom)}
+			},
+			wantCustom: true,
+		},
+		{
+			name: "custom client with transport options",
+			opts: func(custom *http.Client) []Option {
+				return []Option{WithHTTPClient(custom), WithResponseHeaderTimeout(time.Second)}
+			},
+			wantCustom:   true,
+			wantWarnings: 1,
+		},
+		{
+			name: "per-operation clients with transport options",
+			opts: func(custom *http.Client) []Option {
+				return []Option{WithAuthHTTPClient(custom), WithLabelHTTPClient(custom), WithTLSHandshakeTimeout(time.Second)}
+			},
+			wantCustom:   true,
+			wantWarnings: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := dhltest.NewServer()
+			defer srv.Close()
+
+			transport := &countingTransport{}
+			var logs bytes.Buffer
+			opts := append(tt.opts(&http.Client{Transport: transport}),
+				WithBaseURL(srv.BaseURL()),
+				WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+			c, err := NewDHLClient("id", "secret", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 2; i++ {
+				if _, err := c.GetItemLabel(context.Background(), "item-1"); err != nil {
+					t.Fatalf("GetItemLabel: %v", err)
+				}
+			}
+
+			if used := transport.count > 0; used != tt.wantCustom {
+				t.Errorf("custom transport used = %v, want %v", used, tt.wantCustom)
+			}
+			if got := strings.Count(logs.String(), warning); got != tt.wantWarnings {
+				t.Errorf("logged %d warnings, want %d:\n%s", got, tt.wantWarnings, logs.String())
+			}
+		})
+	}
+}
+
+func TestIgnoredTransportWarningWithoutLogger(t *testing.T) {
+	var logs bytes.Buffer
+	defer slog.SetDefault(slog.Default())
+	slog.SetDefault(slog.New(slog.NewTextHandler(&logs, nil)))
+
+	srv := dhltest.NewServer()
+	defer srv.Close()
+
+	c, err := NewDHLClient("id", "secret",
+		WithBaseURL(srv.BaseURL()),
+		WithHTTPClient(&http.Client{}),
+		WithDialTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.GetAccessToken(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if !strings.Contains(logs.String(), "transport options are ignored") {
+		t.Errorf("no warning in the default logger:\n%s", logs.String())
+	}
+}
+
+func TestLazyTransportIsBuiltOnceOnFirstUse(t *testing.T) {
+	c, err := NewDHLClient("id", "secret", WithDialTimeout(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	lt, ok := c.HTTPClient.Transport.(*lazyTransport)
+	if !ok {
+		t.Fatalf("transport is %T, want *lazyTransport", c.HTTPClient.Transport)
+	}
+	if lt.transport != nil {
+		t.Fatal("transport was built before the first request")
+	}
+
+	const goroutines = 16
+	built := make([]*http.Transport, goroutines)
+	var wg sync.WaitGroup
+	for i := range built {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			built[i] = lt.get()
+		}(i)
+	}
+	wg.Wait()
+
+	for i, transport := range built {
+		if transport == nil || transport != built[0] {
+			t.Fatalf("goroutine %d got transport %p, want %p", i, transport, built[0])
+		}
+	}
+}
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+type ProductCode string
+
+const (
+	ProductGPP ProductCode = "GPP"
+	ProductGPT ProductCode = "GPT"
+	ProductGMP ProductCode = "GMP"
+	ProductGMR ProductCode = "GMR"
+	ProductGMM ProductCode = "GMM"
+)
+
+type Order struct {
+	ProductCode     ProductCode      `json:"productCode"`
+	Reference       string           `json:"reference,omitempty"`
+	ShipperDetails  *ShipperDetails  `json:"shipperDetails,omitempty"`
+	ReceiverDetails ReceiverDetails  `json:"receiverDetails"`
+	ShipmentDetails *ShipmentDetails `json:"shipmentDetails,omitempty"`
+	Items           []OrderItem      `json:"items,omitempty"`
+	Services        *Services        `json:"services,omitempty"`
+	Customs         *Customs         `json:"customs,omitempty"`
+	ReturnAddress   *ReturnAddress   `json:"returnAddress,omitempty"`
+	ContentCategory ContentCategory  `json:"contentCategory,omitempty"`
+}
+
+type Services struct {
+	// InsuredValue declares additional coverage for high-value goods. Only
+	// products with ProductCharacteristics.Insurance accept it (GPP, GMR).
+	InsuredValue *Money `json:"insuredValue,omitempty"`
+
+	// PreferredDay (YYYY-MM-DD, after today), PreferredLocation and
+	// PreferredNeighbour are delivery preferences of the receiver. Only
+	// products with ProductCharacteristics.DeliveryPreferences accept them.
+	PreferredDay       string `json:"preferredDay,omitempty"`
+	PreferredLocation  string `json:"preferredLocation,omitempty"`
+	PreferredNeighbour string `json:"preferredNeighbour,omitempty"`
+}
+
+func (s Services) hasDeliveryPreferences() bool {
+	return s.PreferredDay != "" || s.PreferredLocation != "" || s.PreferredNeighbour != ""
+}
+
+func (s Services) validatePreferredDay(now time.Time) error {
+	day, err := time.Parse(time.DateOnly, s.PreferredDay)
+	if err != nil {
+		return fmt.Errorf("want a YYYY-MM-DD date, got %q", s.PreferredDay)
+	}
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if !day.After(today) {
+		return fmt.Errorf("%s is not after today (%s)", s.PreferredDay, today.Format(time.DateOnly))
+	}
+	return nil
+}
+
+type Money struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+func (m Money) validate() error {
+	if m.Amount <= 0 {
+		return fmt.Errorf("amount must be positive, got %v", m.Amount)
+	}
+	if !isCurrencyCode(m.Currency) {
+		return fmt.Errorf("currency %q is not a 3-letter ISO 4217 code", m.Currency)
+	}
+	return nil
+}
+
+func isCurrencyCode(s string) bool {
+	return len(s) == 3 && isLetters(s)
+}
+
+type OrderItem struct {
+	Reference       string          `json:"reference,omitempty"`
+	ShipmentDetails ShipmentDetails `json:"shipmentDetails"`
+	LabelFormat     LabelFormat     `json:"labelFormat,omitempty"`
+}
+
+type Name struct {
+	FirstName string `json:"firstName,omitempty"`
+	LastName  string `json:"lastName"`
+}
+
+type Address struct {
+	Street     string `json:"street"`
+	HouseNo    string `json:"houseNo,omitempty"`
+	PostalCode string `json:"postalCode"`
+	City       string `json:"city"`
+	Country    string `json:"country"`
+}
+
+type ShipperDetails struct {
+	Name    Name    `json:"name"`
+	Address Address `json:"address"`
+}
+
+// ReturnAddress is where DHL sends undeliverable parcels when that is not the
+// shipper, e.g. for drop-shipping. Without it returns go to the shipper.
+type ReturnAddress struct {
+	Name    Name    `json:"name"`
+	Address Address `json:"address"`
+}
+
+// ReceiverDetails is who the parcel is delivered to.
+type ReceiverDetails struct {
+	Name    Name    `json:"name"`
+	Address Address `json:"address"`
+
+	// Email and Phone are sent in DHL's contact block; with an email DHL
+	// sends the receiver a tracking link.
+	Email string `json:"-"`
+	Phone string `json:"-"`
+}
+
+type ShipmentDetails struct {
+	WeightInGrams int `json:"weightInGrams"`
+	Length        int `json:"length,omitempty"`
+	Width         int `json:"width,omitempty"`
+	Height        int `json:"height,omitempty"`
+}
+
+type orderDefaults struct {
+	country     string
+	shipper     *ShipperDetails
+	productCode ProductCode
+}
+
+func WithDefaultCountry(country string) Option {
+	return func(c *DHLClient) {
+		c.defaults.country = country
+	}
+}
+
+func WithDefaultShipper(shipper ShipperDetails) Option {
+	return func(c *DHLClient) {
+		c.defaults.shipper = &shipper
+	}
+}
+
+func WithDefaultProductCode(code ProductCode) Option {
+	return func(c *DHLClient) {
+		c.defaults.productCode = code
+	}
+}
+
+func (d orderDefaults) apply(order Order) Order {
+	if order.ProductCode == "" {
+		order.ProductCode = d.productCode
+	}
+	if order.ShipperDetails == nil && d.shipper != nil {
+		shipper := *d.shipper
+		order.ShipperDetails = &shipper
+	}
+	if order.ReceiverDetails.Address.Country == "" {
+		order.ReceiverDetails.Address.Country = d.country
+	}
+	if order.ShipperDetails != nil && order.ShipperDetails.Address.Country == "" {
+		shipper := *order.ShipperDetails
+		shipper.Address.Country = d.country
+		order.ShipperDetails = &shipper
+	}
+	if order.ReturnAddress != nil && order.ReturnAddress.Address.Country == "" {
+		ret := *order.ReturnAddress
+		ret.Address.Country = d.country
+		order.ReturnAddress = &ret
+	}
+	return order
+}
+
+func (c *DHLClient) applyDefaults(order Order) Order {
+	c.defaultsMu.RLock()
+	defer c.defaultsMu.RUnlock()
+	return c.defaults.apply(order)
+}
+
+// TotalDeclaredValue sums the values of the order's customs items; see
+// Customs.TotalDeclaredValue. Orders without customs declare nothing.
+func (o Order) TotalDeclaredValue() (amount float64, currency string, err error) {
+	if o.Customs == nil {
+		return 0, "", nil
+	}
+	return o.Customs.TotalDeclaredValue()
+}
+
+func (o Order) Validate() error {
+	_, err := o.normalize(time.Now())
+	return err
+}
+
+func (o Order) normalize(now time.Time) (Order, error) {
+	if o.ProductCode == "" {
+		return o, fmt.Errorf("productCode is required")
+	}
+
+	receiver := &o.ReceiverDetails.Address
+	if receiver.City == "" || receiver.PostalCode == "" {
+		return o, fmt.Errorf("receiverDetails.address: city and postalCode are required")
+	}
+	country, err := NormalizeCountry(receiver.Country)
+	if err != nil {
+		return o, fmt.Errorf("receiverDetails.address.country: %w", err)
+	}
+	receiver.Country = country
+	if err := validatePostalCode(country, receiver.PostalCode); err != nil {
+		return o, fmt.Errorf("receiverDetails.address.postalCode: %w", err)
+	}
+
+	if err := o.ReceiverDetails.validateContact(); err != nil {
+		return o, fmt.Errorf("receiverDetails.contact: %w", err)
+	}
+
+	if o.ShipperDetails != nil {
+		shipper := *o.ShipperDetails
+		country, err := NormalizeCountry(shipper.Address.Country)
+		if err != nil {
+			return o, fmt.Errorf("shipperDetails.address.country: %w", err)
+		}
+		shipper.Address.Country = country
+		o.ShipperDetails = &shipper
+	}
+
+	if o.ReturnAddress != nil {
+		ret := *o.ReturnAddress
+		if ret.Address.Street == "" || ret.Address.City == "" || ret.Address.PostalCode == "" {
+			return o, fmt.Errorf("returnAddress.address: street, city and postalCode are required")
+		}
+		country, err := NormalizeCountry(ret.Address.Country)
+		if err != nil {
+			return o, fmt.Errorf("returnAddress.address.country: %w", err)
+		}
+		ret.Address.Country = country
+		if err := validatePostalCode(country, ret.Address.PostalCode); err != nil {
+			return o, fmt.Errorf("returnAddress.address.postalCode: %w", err)
+		}
+		o.ReturnAddress = &ret
+	}
+
+	product, known := ProductInfo(o.ProductCode)
+
+	if len(o.Items) == 0 {
+		if o.ShipmentDetails == nil || o.ShipmentDetails.WeightInGrams <= 0 {
+			return o, fmt.Errorf("shipmentDetails.weightInGrams must be positive")
+		}
+		if known {
+			if err := product.Limits.check(o.ProductCode, *o.ShipmentDetails); err != nil {
+				return o, fmt.Errorf("shipmentDetails: %w", err)
+			}
+		}
+	} else if o.ShipmentDetails != nil && *o.ShipmentDetails == (ShipmentDetails{}) {
+		// Orders with items describe their parcels per item.
+		o.ShipmentDetails = nil
+	}
+	for i, item := range o.Items {
+		if item.ShipmentDetails.WeightInGrams <= 0 {
+			return o, fmt.Errorf("items[%d].shipmentDetails.weightInGrams must be positive", i)
+		}
+		if known {
+			if err := product.Limits.check(o.ProductCode, item.ShipmentDetails); err != nil {
+				return o, fmt.Errorf("items[%d].shipmentDetails: %w", i, err)
+			}
+		}
+		if item.LabelFormat != "" {
+			if err := item.LabelFormat.Validate(); err != nil {
+				return o, fmt.Errorf("items[%d].labelFormat: %w", i, err)
+			}
+		}
+	}
+
+	if o.Customs != nil {
+		customs, err := o.Customs.normalize()
+		if err != nil {
+			return o, fmt.Errorf("customs.%w", err)
+		}
+		o.Customs = &customs
+
+		if customs.DutyPayment == DutyDDP {
+			if known && !product.DutiesPaid {
+				return o, fmt.Errorf("customs.dutyPayment: product %s does not support %s", o.ProductCode, DutyDDP)
+			}
+			if euCustomsUnion[receiver.Country] {
+				return o, fmt.Errorf("customs.dutyPayment: %s does not apply to %s, which is inside the EU customs union", DutyDDP, receiver.Country)
+			}
+		}
+	}
+
+	if o.ContentCategory != "" {
+		if err := o.ContentCategory.validate(o.Customs); err != nil {
+			return o, fmt.Errorf("contentCategory: %w", err)
+		}
+	}
+
+	// DHL rejects an empty services block.
+	if o.Services != nil && *o.Services == (Services{}) {
+		o.Services = nil
+	}
+
+	if o.Services != nil && o.Services.InsuredValue != nil {
+		if known && !product.Insurance {
+			return o, fmt.Errorf("services.insuredValue: product %s does not support insurance", o.ProductCode)
+		}
+		if err := o.Services.InsuredValue.validate(); err != nil {
+			return o, fmt.Errorf("services.insuredValue: %w", err)
+		}
+	}
+
+	if o.Services != nil && o.Services.hasDeliveryPreferences() {
+		if known && !product.DeliveryPreferences {
+			return o, fmt.Errorf("services: product %s does not support delivery preferences", o.ProductCode)
+		}
+		if o.Services.PreferredDay != "" {
+			if err := o.Services.validatePreferredDay(now); err != nil {
+				return o, fmt.Errorf("services.preferredDay: %w", err)
+			}
+		}
+	}
+
+	return o, nil
+}
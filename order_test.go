@@ -0,0 +1,58 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestOrderOmitsEmptyOptionalBlocks(t *testing.T) {
+	order := Order{
+		ProductCode: ProductGPT,
+		ReceiverDetails: ReceiverDetails{
+			Name:    Name{LastName: "Doe"},
+			Address: Address{Street: "Main St", PostalCode: "10115", City: "Berlin", Country: "DE"},
+		},
+		ShipmentDetails: &ShipmentDetails{},
+		Items: []OrderItem{
+			{ShipmentDetails: ShipmentDetails{WeightInGrams: 500}},
+		},
+		Services: &Services{},
+	}
+
+	normalized, err := order.normalize(time.Now())
+	if err != nil {
+		t.Fatalf("normalize: %v", err)
+	}
+	data, err := json.Marshal(normalized)
+	if err != nil {
+		t.Fatalf("marshal: %v", err)
+	}
+
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatalf("unmarshal: %v", err)
+	}
+	for _, key := range []string{"shipmentDetails", "services", "customs", "returnAddress", "shipperDetails", "reference", "contentCategory"} {
+		if _, ok := fields[key]; ok {
+			t.Errorf("%s is sent for an empty block: %s", key, data)
+		}
+	}
+	assertNoNulls(t, "order", fields)
+}
+
+func assertNoNulls(t *testing.T, path string, v any) {
+	t.Helper()
+	switch v := v.(type) {
+	case nil:
+		t.Errorf("%s is null", path)
+	case map[string]any:
+		for key, value := range v {
+			assertNoNulls(t, path+"."+key, value)
+		}
+	case []any:
+		for _, value := range v {
+			assertNoNulls(t, path+"[]", value)
+		}
+	}
+}
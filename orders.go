@@ -0,0 +1,438 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+)
+
+const listOrdersPath = "/shipping/v1/orders"
+
+// OrderFilter narrows ListOrders. CreatedFrom is inclusive and CreatedTo
+// exclusive; zero times leave that end open.
+type OrderFilter struct {
+	Reference   string
+	CreatedFrom time.Time
+	CreatedTo   time.Time
+}
+
+func (f OrderFilter) query() url.Values {
+	q := url.Values{}
+	if f.Reference != "" {
+		q.Set("reference", f.Reference)
+	}
+	if !f.CreatedFrom.IsZero() {
+		q.Set("createdFrom", f.CreatedFrom.UTC().Format(time.RFC3339))
+	}
+	if !f.CreatedTo.IsZero() {
+		q.Set("createdTo", f.CreatedTo.UTC().Format(time.RFC3339))
+	}
+	return q
+}
+
+type listOrdersResponse struct {
+	Orders []OrderDetails `json:"orders"`
+}
+
+func (c *DHLClient) ListOrders(ctx context.Context, filter OrderFilter) (_ []OrderDetails, err error) {
+	defer wrapOp(&err, opListOrders)
+
+	u := c.url(listOrdersPath)
+	if q := filter.query().Encode(); q != "" {
+		u += "?" + q
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	var listResp listOrdersResponse
+	if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return listResp.Orders, nil
+}
+
+// ListOrdersByDateRange returns the orders created in [from, to), oldest
+// first, with CreatedAt in UTC. from and to may be in any time zone.
+func (c *DHLClient) ListOrdersByDateRange(ctx context.Context, from, to time.Time) (_ []OrderDetails, err error) {
+	defer wrapOp(&err, opListOrders)
+
+	if !from.Before(to) {
+		return nil, fmt.Errorf("date range start %s is not before its end %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+
+	orders, err := c.ListOrders(ctx, OrderFilter{CreatedFrom: from, CreatedTo: to})
+	if err != nil {
+		return nil, err
+	}
+
+	// Filter again in case DHL's bounds are inclusive on both ends.
+	var inRange []OrderDetails
+	for _, order := range orders {
+		if !order.CreatedAt.Before(from) && order.CreatedAt.Before(to) {
+			order.CreatedAt = order.CreatedAt.UTC()
+			inRange = append(inRange, order)
+		}
+	}
+	sort.SliceStable(inRange, func(i, j int) bool {
+		return inRange[i].CreatedAt.Before(inRange[j].CreatedAt)
+	})
+
+	return inRange, nil
+}
+
+// CreateOrderIfAbsent creates the order unless an order with the same
+// reference already exists, in which case the existing order id is returned
+// and created is false.
+//
+// The lookup and the create are two separate calls, so two concurrent
+// callers using the same reference can both see no match and both create an
+// order. Serialize creates per reference on the caller side if that matters.
+func (c *DHLClient) CreateOrderIfAbsent(ctx context.Context, order Order) (orderID string, created bool, err error) {
+	defer wrapOp(&err, opCreateOrder)
+
+	if order.Reference == "" {
+		return "", false, errors.New("order reference is required for deduplication")
+	}
+
+	existing, err := c.ListOrders(ctx, OrderFilter{Reference: order.Reference})
+	if err != nil {
+		return "", false, fmt.Errorf("looking up reference %q: %w", order.Reference, err)
+	}
+	for _, o := range existing {
+		if o.Reference == order.Reference {
+			return o.OrderID, false, nil
+		}
+	}
+
+	orderID, err = c.CreateOrder(ctx, order)
+	if err != nil {
+		return "", false, err
+	}
+
+	return orderID, true, nil
+}
+
+const (
+	deleteOrderPath  = "/shipping/v1/orders/%s"
+	voidShipmentPath = "/shipping/v1/items/%s/void"
+)
+
+type VoidConfirmation struct {
+	ItemID       string  `json:"itemId"`
+	Status       string  `json:"status"`
+	RefundAmount float64 `json:"refundAmount,omitempty"`
+	Currency     string  `json:"currency,omitempty"`
+}
+
+// DeleteOrder cancels an order that has not been manifested yet. Nothing has
+// been handed over to DHL at that point, so the order is simply removed.
+// Use VoidShipment for items that were already manifested; deleting their
+// order fails with ErrAlreadyManifested.
+func (c *DHLClient) DeleteOrder(ctx context.Context, orderID string) (err error) {
+	defer wrapOp(&err, opDeleteOrder)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.url(deleteOrderPath, orderID), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return err
+	}
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return newAPIError(resp)
+	}
+
+	return nil
+}
+
+const deleteOrdersConcurrency = 4
+
+// DeleteOrderResult is the outcome of deleting one order with DeleteOrders.
+// AlreadyManifested is set when Err is ErrAlreadyManifested.
+type DeleteOrderResult struct {
+	OrderID           string
+	Err               error
+	AlreadyManifested bool
+}
+
+// DeleteOrders deletes the orders concurrently, e.g. to clean up unshipped
+// test orders at the end of the day. The results hold one entry per order in
+// input order; the error reports the failed ones, if any.
+func (c *DHLClient) DeleteOrders(ctx context.Context, orderIDs []string) (_ []DeleteOrderResult, err error) {
+	defer wrapOp(&err, opDeleteOrders)
+
+	results := make([]DeleteOrderResult, len(orderIDs))
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, deleteOrdersConcurrency)
+	for i, orderID := range orderIDs {
+		results[i] = DeleteOrderResult{OrderID: orderID}
+
+		wg.Add(1)
+		go func(result *DeleteOrderResult) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				result.Err = ctx.Err()
+				return
+			}
+			result.Err = c.DeleteOrder(ctx, result.OrderID)
+			result.AlreadyManifested = errors.Is(result.Err, ErrAlreadyManifested)
+		}(&results[i])
+	}
+	wg.Wait()
+
+	var failures []error
+	for _, result := range results {
+		if result.Err != nil {
+			failures = append(failures, fmt.Errorf("order %s: %w", result.OrderID, result.Err))
+		}
+	}
+	if len(failures) > 0 {
+		return results, fmt.Errorf("%d of %d orders not deleted: %w", len(failures), len(results), errors.Join(failures...))
+	}
+
+	return results, nil
+}
+
+// VoidShipment reverses an item after it has been manifested. Unlike
+// DeleteOrder it goes through DHL's void flow, which may be rejected once
+// the parcel is in the network and reports any refund in the confirmation.
+func (c *DHLClient) VoidShipment(ctx context.Context, itemID string) (_ *VoidConfirmation, err error) {
+	defer wrapOp(&err, opVoidShipment)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(voidShipmentPath, itemID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	var confirmation VoidConfirmation
+	if err := json.NewDecoder(resp.Body).Decode(&confirmation); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return &confirmation, nil
+}
+
+// OrderResult describes an order created with CreateOrderResult.
+type OrderResult struct {
+	OrderID         string
+	ItemIDs         []string
+	TrackingNumbers []string
+	Duration        time.Duration
+	Attempts        int
+
+	// RoutingCodes holds one entry per ItemIDs entry, empty where DHL
+	// assigned no routing code.
+	RoutingCodes []string
+}
+
+// CreateOrderResult creates the order like CreateOrder and additionally
+// reports the created items, the wall time of the call and the number of
+// HTTP attempts it took, including retries.
+func (c *DHLClient) CreateOrderResult(ctx context.Context, order Order) (_ *OrderResult, err error) {
+	defer wrapOp(&err, opCreateOrder)
+
+	var stats callStats
+	start := c.now()
+
+	createOrderResp, err := c.createTypedOrder(withCallStats(ctx, &stats), order)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &OrderResult{
+		OrderID:         createOrderResp.OrderID,
+		TrackingNumbers: createOrderResp.TrackingNumbers(),
+		Duration:        c.now().Sub(start),
+		Attempts:        stats.attempts,
+	}
+	for _, item := range createOrderResp.items() {
+		result.ItemIDs = append(result.ItemIDs, item.ID)
+		result.RoutingCodes = append(result.RoutingCodes, item.RoutingCode)
+	}
+
+	return result, nil
+}
+
+const referenceLookupConcurrency = 4
+
+// GetOrdersByReferences looks up the orders for refs, querying DHL
+// concurrently since the listing filters on a single reference. References
+// without an order are returned in missing; lookups that fail are reported
+// in the error while the found orders are still returned. Repeated
+// references are looked up once.
+func (c *DHLClient) GetOrdersByReferences(ctx context.Context, refs []string) (found map[string]OrderDetails, missing []string, err error) {
+	defer wrapOp(&err, opListOrders)
+
+	seen := make(map[string]bool, len(refs))
+	var unique []string
+	for _, ref := range refs {
+		if !seen[ref] {
+			seen[ref] = true
+			unique = append(unique, ref)
+		}
+	}
+	refs = unique
+
+	type lookup struct {
+		orders []OrderDetails
+		err    error
+	}
+	results := make([]lookup, len(refs))
+
+	var wg sync.WaitGroup
+	slots := make(chan struct{}, referenceLookupConcurrency)
+	for i, ref := range refs {
+		wg.Add(1)
+		go func(i int, ref string) {
+			defer wg.Done()
+			select {
+			case slots <- struct{}{}:
+				defer func() { <-slots }()
+			case <-ctx.Done():
+				results[i] = lookup{err: ctx.Err()}
+				return
+			}
+			orders, err := c.ListOrders(ctx, OrderFilter{Reference: ref})
+			results[i] = lookup{orders: orders, err: err}
+		}(i, ref)
+	}
+	wg.Wait()
+
+	found = make(map[string]OrderDetails)
+	var failures []error
+	for i, ref := range refs {
+		if results[i].err != nil {
+			failures = append(failures, fmt.Errorf("reference %q: %w", ref, results[i].err))
+			continue
+		}
+		matched := false
+		for _, order := range results[i].orders {
+			if order.Reference == ref {
+				found[ref] = order
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			missing = append(missing, ref)
+		}
+	}
+
+	return found, missing, errors.Join(failures...)
+}
+
+const previewOrderPath = "/shipping/v1/orders/validation"
+
+// OrderPreview is DHL's verdict on an order submitted with PreviewOrder.
+type OrderPreview struct {
+	Valid    bool             `json:"valid"`
+	Warnings []APIErrorDetail `json:"warnings,omitempty"`
+	Errors   []APIErrorDetail `json:"errors,omitempty"`
+}
+
+// PreviewOrder has DHL validate the order without creating it, catching
+// problems only DHL knows about such as embargoed postal codes. The order
+// is validated locally first. DHL's rejections are reported in the preview,
+// not as an error.
+func (c *DHLClient) PreviewOrder(ctx context.Context, order Order) (_ *OrderPreview, err error) {
+	defer wrapOp(&err, opPreviewOrder)
+
+	order, err = c.applyDefaults(order).normalize(c.now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidOrder, err)
+	}
+
+	jsonData, err := c.marshal(order)
+	if err != nil {
+		return nil, fmt.Errorf("marshaling order data: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(previewOrderPath), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Content-Type", c.contentType)
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, jsonData)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusBadRequest, http.StatusUnprocessableEntity:
+		apiErr := newAPIError(resp)
+		if len(apiErr.Errors) == 0 {
+			return nil, apiErr
+		}
+		return &OrderPreview{Errors: apiErr.Errors}, nil
+	default:
+		return nil, newAPIError(resp)
+	}
+
+	var preview OrderPreview
+	if err := json.NewDecoder(resp.Body).Decode(&preview); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return &preview, nil
+}
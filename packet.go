@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"image/png"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+const (
+	getItemPath   = "/shipping/v1/items/%s"
+	listItemsPath = "/shipping/v1/items"
+)
+
+type ItemContent struct {
+	Description string `json:"description"`
+	Quantity    int    `json:"quantity"`
+}
+
+type ItemDetails struct {
+	ID              string          `json:"id"`
+	Barcode         string          `json:"barcode"`
+	OrderID         string          `json:"orderId"`
+	Reference       string          `json:"reference,omitempty"`
+	ProductCode     ProductCode     `json:"productCode"`
+	ReceiverDetails ReceiverDetails `json:"receiverDetails"`
+	ShipmentDetails ShipmentDetails `json:"shipmentDetails"`
+	Contents        []ItemContent   `json:"contents,omitempty"`
+	RoutingCode     string          `json:"routingCode,omitempty"`
+	SortCode        string          `json:"sortCode,omitempty"`
+}
+
+func (c *DHLClient) GetItem(ctx context.Context, itemID string) (_ *ItemDetails, err error) {
+	defer wrapOp(&err, opGetItem)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(getItemPath, itemID), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	var item ItemDetails
+	if err := json.NewDecoder(resp.Body).Decode(&item); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return &item, nil
+}
+
+type listItemsResponse struct {
+	Items []ItemDetails `json:"items"`
+}
+
+// findItemByBarcode looks up the item whose barcode, the tracking number
+// printed on its label, is barcode.
+func (c *DHLClient) findItemByBarcode(ctx context.Context, barcode string) (*ItemDetails, error) {
+	u := c.url(listItemsPath) + "?" + url.Values{"barcode": {barcode}}.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	var listResp listItemsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&listResp); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	for _, item := range listResp.Items {
+		if strings.EqualFold(item.Barcode, barcode) {
+			return &item, nil
+		}
+	}
+	return nil, fmt.Errorf("no item with barcode %s: %w", barcode, ErrNotFound)
+}
+
+// GenerateOrderPacket renders a one-page PDF with a human-readable summary of
+// the item (receiver, contents, reference) above its shipping label. The
+// label is requested as PNG so it can be embedded in the page.
+func (c *DHLClient) GenerateOrderPacket(ctx context.Context, itemID string) (_ []byte, err error) {
+	defer wrapOp(&err, opOrderPacket)
+
+	item, err := c.GetItem(ctx, itemID)
+	if err != nil {
+		return nil, err
+	}
+
+	label, err := c.fetchLabel(ctx, itemID, LabelOptions{Format: LabelFormatPNG})
+	if err != nil {
+		return nil, err
+	}
+	if label.Format() != LabelFormatPNG {
+		return nil, fmt.Errorf("label for item %s is %s, need %s to build the packet", itemID, label.Format(), LabelFormatPNG)
+	}
+	img, err := png.Decode(bytes.NewReader(label.Data))
+	if err != nil {
+		return nil, fmt.Errorf("decoding PNG label: %w", err)
+	}
+
+	return renderPacketPDF("Order "+item.OrderID, item.summaryLines(), img)
+}
+
+func (item *ItemDetails) summaryLines() []string {
+	receiver := item.ReceiverDetails
+	lines := []string{
+		"Item: " + item.ID,
+		"Tracking number: " + item.Barcode,
+	}
+	if item.Reference != "" {
+		lines = append(lines, "Reference: "+item.Reference)
+	}
+	lines = append(lines,
+		fmt.Sprintf("Product: %s, %dg", item.ProductCode, item.ShipmentDetails.WeightInGrams),
+		"",
+		"Receiver: "+strings.TrimSpace(receiver.Name.FirstName+" "+receiver.Name.LastName),
+		strings.TrimSpace(receiver.Address.Street+" "+receiver.Address.HouseNo),
+		strings.TrimSpace(receiver.Address.PostalCode+" "+receiver.Address.City),
+		receiver.Address.Country,
+	)
+	if len(item.Contents) > 0 {
+		lines = append(lines, "", "Contents:")
+		for _, content := range item.Contents {
+			lines = append(lines, fmt.Sprintf("  %d x %s", content.Quantity, content.Description))
+		}
+	}
+	return lines
+}
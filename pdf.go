@@ -0,0 +1,126 @@
+package main
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"image"
+	"strings"
+)
+
+const (
+	pdfPageWidth  = 595
+	pdfPageHeight = 842
+	pdfMargin     = 40
+	pdfFontSize   = 11
+	pdfLeading    = 15
+	pdfTitleSize  = 16
+)
+
+// renderPacketPDF renders a single A4 page with title and lines as a text
+// header and img scaled to fit the remaining space below it.
+func renderPacketPDF(title string, lines []string, img image.Image) ([]byte, error) {
+	var content bytes.Buffer
+	y := pdfPageHeight - pdfMargin - pdfTitleSize
+	fmt.Fprintf(&content, "BT /F1 %d Tf %d %d Td (%s) Tj ET\n", pdfTitleSize, pdfMargin, y, pdfEscape(title))
+	y -= pdfLeading + 6
+	for _, line := range lines {
+		fmt.Fprintf(&content, "BT /F1 %d Tf %d %d Td (%s) Tj ET\n", pdfFontSize, pdfMargin, y, pdfEscape(line))
+		y -= pdfLeading
+	}
+
+	bounds := img.Bounds()
+	imgWidth, imgHeight := bounds.Dx(), bounds.Dy()
+	if imgWidth == 0 || imgHeight == 0 {
+		return nil, fmt.Errorf("label image is empty")
+	}
+	availWidth := float64(pdfPageWidth - 2*pdfMargin)
+	availHeight := float64(y - pdfMargin)
+	scale := availWidth / float64(imgWidth)
+	if s := availHeight / float64(imgHeight); s < scale {
+		scale = s
+	}
+	drawWidth, drawHeight := float64(imgWidth)*scale, float64(imgHeight)*scale
+	fmt.Fprintf(&content, "q %.2f 0 0 %.2f %d %.2f cm /Im1 Do Q\n", drawWidth, drawHeight, pdfMargin, float64(y)-drawHeight)
+
+	pixels, err := pdfImageData(img)
+	if err != nil {
+		return nil, err
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 4 0 R >> /XObject << /Im1 6 0 R >> >> /Contents 5 0 R >>", pdfPageWidth, pdfPageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Helvetica /Encoding /WinAnsiEncoding >>",
+		pdfStream("", content.Bytes()),
+		pdfStream(fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceRGB /BitsPerComponent 8 /Filter /FlateDecode", imgWidth, imgHeight), pixels),
+	}
+
+	var out bytes.Buffer
+	out.WriteString("%PDF-1.4\n%\xe2\xe3\xcf\xd3\n")
+	offsets := make([]int, len(objects))
+	for i, obj := range objects {
+		offsets[i] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", i+1, obj)
+	}
+
+	xref := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&out, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+
+	return out.Bytes(), nil
+}
+
+func pdfStream(dict string, data []byte) string {
+	if dict != "" {
+		dict += " "
+	}
+	return fmt.Sprintf("<< %s/Length %d >>\nstream\n%s\nendstream", dict, len(data), data)
+}
+
+func pdfImageData(img image.Image) ([]byte, error) {
+	bounds := img.Bounds()
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	row := make([]byte, 0, bounds.Dx()*3)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		row = row[:0]
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			// Composite onto white so transparent label backgrounds print white.
+			r, g, b = r+(0xffff-a), g+(0xffff-a), b+(0xffff-a)
+			row = append(row, byte(r>>8), byte(g>>8), byte(b>>8))
+		}
+		if _, err := zw.Write(row); err != nil {
+			return nil, fmt.Errorf("compressing label image: %w", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, fmt.Errorf("compressing label image: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// pdfEscape escapes a string for a PDF literal, mapping runes outside
+// Latin-1 to '?' since the page uses the standard Helvetica font.
+func pdfEscape(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			b.WriteByte('\\')
+			b.WriteByte(byte(r))
+		case r < 0x20:
+			b.WriteByte(' ')
+		case r < 0x80 || (r >= 0xa0 && r <= 0xff):
+			b.WriteByte(byte(r))
+		default:
+			b.WriteByte('?')
+		}
+	}
+	return b.String()
+}
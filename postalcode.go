@@ -0,0 +1,94 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+type postalCodeFormat struct {
+	pattern *regexp.Regexp
+	format  string
+}
+
+func postalFormat(pattern, format string) postalCodeFormat {
+	return postalCodeFormat{pattern: regexp.MustCompile(`^(?:` + pattern + `)$`), format: format}
+}
+
+// postalCodeFormats covers the countries of the country table that use
+// postal codes. Codes are matched case-insensitively after trimming.
+var postalCodeFormats = map[string]postalCodeFormat{
+	"AT": postalFormat(`\d{4}`, "4 digits"),
+	"AU": postalFormat(`\d{4}`, "4 digits"),
+	"BE": postalFormat(`\d{4}`, "4 digits"),
+	"BG": postalFormat(`\d{4}`, "4 digits"),
+	"BR": postalFormat(`\d{5}-?\d{3}`, "8 digits, e.g. 01310-100"),
+	"CA": postalFormat(`[A-Z]\d[A-Z] ?\d[A-Z]\d`, "A9A 9A9"),
+	"CH": postalFormat(`\d{4}`, "4 digits"),
+	"CN": postalFormat(`\d{6}`, "6 digits"),
+	"CY": postalFormat(`\d{4}`, "4 digits"),
+	"CZ": postalFormat(`\d{3} ?\d{2}`, "5 digits, e.g. 110 00"),
+	"DE": postalFormat(`\d{5}`, "5 digits"),
+	"DK": postalFormat(`\d{4}`, "4 digits"),
+	"EE": postalFormat(`\d{5}`, "5 digits"),
+	"ES": postalFormat(`\d{5}`, "5 digits"),
+	"FI": postalFormat(`\d{5}`, "5 digits"),
+	"FR": postalFormat(`\d{5}`, "5 digits"),
+	"GB": postalFormat(`[A-Z]{1,2}\d[A-Z\d]? ?\d[A-Z]{2}`, "alphanumeric outward and inward code, e.g. SW1A 1AA"),
+	"GR": postalFormat(`\d{3} ?\d{2}`, "5 digits"),
+	"HR": postalFormat(`\d{5}`, "5 digits"),
+	"HU": postalFormat(`\d{4}`, "4 digits"),
+	"IE": postalFormat(`[A-Z]\d[\dW] ?[A-Z\d]{4}`, "Eircode, e.g. D02 X285"),
+	"IL": postalFormat(`\d{7}`, "7 digits"),
+	"IN": postalFormat(`\d{6}`, "6 digits"),
+	"IS": postalFormat(`\d{3}`, "3 digits"),
+	"IT": postalFormat(`\d{5}`, "5 digits"),
+	"JP": postalFormat(`\d{3}-?\d{4}`, "7 digits, e.g. 100-0001"),
+	"KR": postalFormat(`\d{5}`, "5 digits"),
+	"LI": postalFormat(`\d{4}`, "4 digits"),
+	"LT": postalFormat(`(?:LT-)?\d{5}`, "5 digits"),
+	"LU": postalFormat(`(?:L-)?\d{4}`, "4 digits"),
+	"LV": postalFormat(`(?:LV-)?\d{4}`, "4 digits"),
+	"MT": postalFormat(`[A-Z]{3} ?\d{4}`, "AAA 9999"),
+	"MX": postalFormat(`\d{5}`, "5 digits"),
+	"NL": postalFormat(`\d{4} ?[A-Z]{2}`, "4 digits and 2 letters, e.g. 1012 AB"),
+	"NO": postalFormat(`\d{4}`, "4 digits"),
+	"NZ": postalFormat(`\d{4}`, "4 digits"),
+	"PL": postalFormat(`\d{2}-?\d{3}`, "99-999"),
+	"PT": postalFormat(`\d{4}-?\d{3}`, "9999-999"),
+	"RO": postalFormat(`\d{6}`, "6 digits"),
+	"RS": postalFormat(`\d{5}`, "5 digits"),
+	"SE": postalFormat(`\d{3} ?\d{2}`, "5 digits, e.g. 111 22"),
+	"SG": postalFormat(`\d{6}`, "6 digits"),
+	"SI": postalFormat(`(?:SI-)?\d{4}`, "4 digits"),
+	"SK": postalFormat(`\d{3} ?\d{2}`, "5 digits"),
+	"TR": postalFormat(`\d{5}`, "5 digits"),
+	"UA": postalFormat(`\d{5}`, "5 digits"),
+	"US": postalFormat(`\d{5}(?:-\d{4})?`, "ZIP code, 5 digits or ZIP+4"),
+	"ZA": postalFormat(`\d{4}`, "4 digits"),
+}
+
+// PostalCodeError reports a postal code that does not match its country's
+// format.
+type PostalCodeError struct {
+	Country    string
+	PostalCode string
+	Format     string
+}
+
+func (e *PostalCodeError) Error() string {
+	return fmt.Sprintf("postal code %q is not valid for %s, expected %s", e.PostalCode, e.Country, e.Format)
+}
+
+// validatePostalCode checks code against the format of country, an alpha-2
+// code. Countries without a known format accept any code.
+func validatePostalCode(country, code string) error {
+	format, ok := postalCodeFormats[country]
+	if !ok {
+		return nil
+	}
+	if !format.pattern.MatchString(strings.ToUpper(strings.TrimSpace(code))) {
+		return &PostalCodeError{Country: country, PostalCode: code, Format: format.format}
+	}
+	return nil
+}
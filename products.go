@@ -0,0 +1,164 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	listProductsPath   = "/shipping/v1/products"
+	serviceabilityPath = "/shipping/v1/products/%s/serviceability"
+)
+
+type ProductCharacteristics struct {
+	Name          string
+	Tracking      bool
+	International bool
+	Returns       bool
+	Signature     bool
+	Insurance     bool
+	DirectLabel   bool
+	Limits        ParcelLimits
+
+	DeliveryPreferences bool
+	DutiesPaid          bool
+}
+
+// ParcelLimits are the maximum weight in grams and dimensions in centimeters
+// DHL accepts for a product. MaxDimensionSumCM bounds length+width+height.
+type ParcelLimits struct {
+	MaxWeightGrams    int
+	MaxLengthCM       int
+	MaxDimensionSumCM int
+}
+
+var packetLimits = ParcelLimits{MaxWeightGrams: 2000, MaxLengthCM: 60, MaxDimensionSumCM: 90}
+
+var productCatalog = map[ProductCode]ProductCharacteristics{
+	ProductGPP: {Name: "Packet Plus", Tracking: true, International: true, Returns: true, Signature: true, Insurance: true, DirectLabel: true, Limits: packetLimits, DeliveryPreferences: true, DutiesPaid: true},
+	ProductGPT: {Name: "Packet Tracked", Tracking: true, International: true, DirectLabel: true, Limits: packetLimits, DutiesPaid: true},
+	ProductGMP: {Name: "Packet", International: true, Limits: packetLimits},
+	ProductGMR: {Name: "Business Mail Registered", Tracking: true, International: true, Signature: true, Insurance: true, Limits: packetLimits},
+	ProductGMM: {Name: "Business Mail Standard", International: true, Limits: packetLimits},
+}
+
+func (l ParcelLimits) check(code ProductCode, parcel ShipmentDetails) error {
+	if l.MaxWeightGrams > 0 && parcel.WeightInGrams > l.MaxWeightGrams {
+		return fmt.Errorf("weight %dg exceeds %s max of %dg", parcel.WeightInGrams, code, l.MaxWeightGrams)
+	}
+	if l.MaxLengthCM > 0 {
+		for _, side := range []int{parcel.Length, parcel.Width, parcel.Height} {
+			if side > l.MaxLengthCM {
+				return fmt.Errorf("side length %dcm exceeds %s max of %dcm", side, code, l.MaxLengthCM)
+			}
+		}
+	}
+	if sum := parcel.Length + parcel.Width + parcel.Height; l.MaxDimensionSumCM > 0 && sum > l.MaxDimensionSumCM {
+		return fmt.Errorf("dimensions sum %dcm exceeds %s max of %dcm", sum, code, l.MaxDimensionSumCM)
+	}
+	return nil
+}
+
+// ProductInfo reports the service characteristics of a DHL product code.
+// The second result is false for codes missing from the built-in table.
+func ProductInfo(code ProductCode) (ProductCharacteristics, bool) {
+	info, ok := productCatalog[code]
+	return info, ok
+}
+
+type productsResponse struct {
+	Products []struct {
+		Code ProductCode `json:"code"`
+		Name string      `json:"name"`
+	} `json:"products"`
+}
+
+func (c *DHLClient) ListProducts(ctx context.Context) (_ []ProductCode, err error) {
+	defer wrapOp(&err, opListProducts)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(listProductsPath), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	var productsResp productsResponse
+	if err := json.NewDecoder(resp.Body).Decode(&productsResp); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	codes := make([]ProductCode, 0, len(productsResp.Products))
+	for _, product := range productsResp.Products {
+		codes = append(codes, product.Code)
+	}
+
+	return codes, nil
+}
+
+// Serviceability reports whether a product delivers to a destination.
+// Restrictions lists DHL's caveats, e.g. excluded islands or goods.
+type Serviceability struct {
+	Available    bool     `json:"available"`
+	Restrictions []string `json:"restrictions,omitempty"`
+}
+
+// CheckServiceability asks DHL whether code can be shipped to destination.
+// Only the country and, if set, the postal code of destination are used.
+func (c *DHLClient) CheckServiceability(ctx context.Context, code ProductCode, destination Address) (_ *Serviceability, err error) {
+	defer wrapOp(&err, opCheckService)
+
+	country, err := NormalizeCountry(destination.Country)
+	if err != nil {
+		return nil, fmt.Errorf("destination country: %w", err)
+	}
+
+	q := url.Values{}
+	q.Set("country", country)
+	if destination.PostalCode != "" {
+		q.Set("postalCode", destination.PostalCode)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.url(serviceabilityPath, url.PathEscape(string(code)))+"?"+q.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	var serviceability Serviceability
+	if err := json.NewDecoder(resp.Body).Decode(&serviceability); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	return &serviceability, nil
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+type RecordedOrder struct {
+	OrderID         string    `json:"orderId"`
+	TrackingNumbers []string  `json:"trackingNumbers"`
+	Reference       string    `json:"reference,omitempty"`
+	CreatedAt       time.Time `json:"createdAt"`
+}
+
+// Recorder is an in-memory ledger of the orders successfully created by a
+// client. It is independent of DHL's own order listing.
+type Recorder struct {
+	mu      sync.Mutex
+	orders  []RecordedOrder
+	streams []*ledgerStream
+}
+
+type ledgerStream struct {
+	enc *json.Encoder
+	w   io.Writer
+	err error
+}
+
+func (s *ledgerStream) write(order RecordedOrder) {
+	if s.err != nil {
+		return
+	}
+	if s.err = s.enc.Encode(order); s.err != nil {
+		return
+	}
+	switch f := s.w.(type) {
+	case interface{ Flush() error }:
+		s.err = f.Flush()
+	case interface{ Flush() }:
+		f.Flush()
+	}
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{}
+}
+
+func WithRecorder(r *Recorder) Option {
+	return func(c *DHLClient) {
+		c.recorder = r
+	}
+}
+
+func (r *Recorder) record(resp *CreateOrderResponse, reference string, createdAt time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	order := RecordedOrder{
+		OrderID:         resp.OrderID,
+		TrackingNumbers: resp.TrackingNumbers(),
+		Reference:       reference,
+		CreatedAt:       createdAt.UTC(),
+	}
+	if order.TrackingNumbers == nil {
+		order.TrackingNumbers = []string{}
+	}
+	r.orders = append(r.orders, order)
+	for _, stream := range r.streams {
+		stream.write(order)
+	}
+}
+
+// StreamLedger writes the recorded orders to w as newline-delimited JSON,
+// first those recorded so far and then each new one as it is created,
+// flushing w after every record if it has a Flush method. The returned
+// function stops the stream and reports the first write error; w is not
+// written to after a failed write.
+func (r *Recorder) StreamLedger(w io.Writer) (stop func() error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	stream := &ledgerStream{enc: json.NewEncoder(w), w: w}
+	for _, order := range r.orders {
+		stream.write(order)
+	}
+	r.streams = append(r.streams, stream)
+
+	return func() error {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+
+		for i, s := range r.streams {
+			if s == stream {
+				r.streams = append(r.streams[:i], r.streams[i+1:]...)
+				break
+			}
+		}
+		return stream.err
+	}
+}
+
+func (r *Recorder) Orders() []RecordedOrder {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	orders := make([]RecordedOrder, len(r.orders))
+	copy(orders, r.orders)
+	return orders
+}
+
+func (r *Recorder) ExportCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write([]string{"order_id", "tracking_numbers", "reference", "created_at"}); err != nil {
+		return err
+	}
+
+	for _, order := range r.Orders() {
+		record := []string{
+			order.OrderID,
+			strings.Join(order.TrackingNumbers, ";"),
+			order.Reference,
+			order.CreatedAt.Format(time.RFC3339),
+		}
+		if err := cw.Write(record); err != nil {
+			return err
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+func (r *Recorder) ExportJSON(w io.Writer) error {
+	orders := r.Orders()
+	if orders == nil {
+		orders = []RecordedOrder{}
+	}
+	return json.NewEncoder(w).Encode(orders)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RetryPolicy controls how failed requests are retried. Retries only apply
+// to idempotent requests: GET, HEAD, PUT, DELETE and OPTIONS, token and ZPL
+// render requests, and POSTs carrying an idempotency key (see
+// WithIdempotencyKey).
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+
+	// RetryNonIdempotent retries every request, at the risk of creating
+	// duplicate orders.
+	RetryNonIdempotent bool
+}
+
+const idempotencyKeyHeader = "Idempotency-Key"
+
+type idempotencyKeyKey struct{}
+
+// WithIdempotencyKey returns a context whose create requests carry key as
+// their idempotency key, letting DHL deduplicate them and the client retry
+// them safely.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// withIdempotencyKey returns req carrying the idempotency key of its context,
+// if any.
+func withIdempotencyKey(req *http.Request) *http.Request {
+	key, _ := req.Context().Value(idempotencyKeyKey{}).(string)
+	if key == "" || req.Method != http.MethodPost || req.Header.Get(idempotencyKeyHeader) != "" {
+		return req
+	}
+	req = req.Clone(req.Context())
+	req.Header.Set(idempotencyKeyHeader, key)
+	return req
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodPut, http.MethodDelete, http.MethodOptions:
+		return true
+	}
+	if kind := opKindFrom(req.Context()); kind == opKindAuth || kind == opKindRender {
+		return true
+	}
+	return req.Header.Get(idempotencyKeyHeader) != ""
+}
+
+var defaultRetryPolicy = RetryPolicy{
+	MaxAttempts: 1,
+	BaseDelay:   500 * time.Millisecond,
+	MaxDelay:    30 * time.Second,
+}
+
+func WithRetryPolicy(policy RetryPolicy) Option {
+	return func(c *DHLClient) {
+		if policy.MaxAttempts < 1 {
+			policy.MaxAttempts = 1
+		}
+		c.retry = policy
+	}
+}
+
+// WithAuthRetry gives token requests their own retry policy. Without it
+// they follow the policy set with WithRetryPolicy.
+func WithAuthRetry(policy RetryPolicy) Option {
+	return func(c *DHLClient) {
+		if policy.MaxAttempts < 1 {
+			policy.MaxAttempts = 1
+		}
+		c.authRetry = &policy
+	}
+}
+
+func (c *DHLClient) retryPolicyFor(ctx context.Context) RetryPolicy {
+	if c.authRetry != nil && opKindFrom(ctx) == opKindAuth {
+		return *c.authRetry
+	}
+	return c.retry
+}
+
+// WithRetrySeed seeds the jitter source so retry delays are reproducible.
+func WithRetrySeed(seed int64) Option {
+	return func(c *DHLClient) {
+		c.jitter = newJitterSource(seed)
+	}
+}
+
+// WithRetryDecider replaces the default rule of retrying transport errors
+// and 429/5xx responses. decide sees either the response or the transport
+// error of the failed attempt; MaxAttempts still caps the retries.
+func WithRetryDecider(decide func(resp *http.Response, err error) bool) Option {
+	return func(c *DHLClient) {
+		c.retryDecider = decide
+	}
+}
+
+func (c *DHLClient) shouldRetry(resp *http.Response, err error) bool {
+	if c.retryDecider != nil {
+		return c.retryDecider(resp, err)
+	}
+	if err != nil {
+		return true
+	}
+	return isRetryableStatus(resp.StatusCode) && !isQuotaExceeded(resp)
+}
+
+// isQuotaExceeded peeks at the error body of resp, leaving it readable for
+// the caller.
+func isQuotaExceeded(resp *http.Response) bool {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), resp.Body), resp.Body}
+	if err != nil {
+		return false
+	}
+	return errors.Is(ParseAPIError(resp.StatusCode, body), ErrQuotaExceeded)
+}
+
+type jitterSource struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+func newJitterSource(seed int64) *jitterSource {
+	return &jitterSource{rnd: rand.New(rand.NewSource(seed))}
+}
+
+func (j *jitterSource) int63n(n int64) int64 {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.rnd.Int63n(n)
+}
+
+// backoff returns the full-jitter delay before the given retry attempt
+// (1-based): a random duration in [0, min(MaxDelay, BaseDelay*2^(attempt-1))).
+// A zero MaxDelay leaves the delay uncapped.
+func (p RetryPolicy) backoff(attempt int, jitter *jitterSource) time.Duration {
+	delay := p.BaseDelay
+	for i := 1; i < attempt; i++ {
+		if p.MaxDelay > 0 && delay >= p.MaxDelay {
+			break
+		}
+		if delay > math.MaxInt64/2 {
+			delay = math.MaxInt64
+			break
+		}
+		delay *= 2
+	}
+	if p.MaxDelay > 0 && delay > p.MaxDelay {
+		delay = p.MaxDelay
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return time.Duration(jitter.int63n(int64(delay)))
+}
+
+func isRetryableStatus(code int) bool {
+	switch code {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
+
+// parseRetryAfter interprets a Retry-After header given either as a number of
+// seconds or as an HTTP-date. Dates in the past yield a zero delay.
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+	if delay := at.Sub(now); delay > 0 {
+		return delay, true
+	}
+	return 0, true
+}
+
+// WithSleep replaces the function used to wait between retries and polls.
+// It must return early with ctx.Err() once ctx is done. Together with
+// WithClock this makes time-dependent behavior deterministic in tests.
+func WithSleep(sleep func(ctx context.Context, d time.Duration) error) Option {
+	return func(c *DHLClient) {
+		c.sleep = sleep
+	}
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2024, time.March, 1, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		value     string
+		wantDelay time.Duration
+		wantOK    bool
+	}{
+		{value: "120", wantDelay: 2 * time.Minute, wantOK: true},
+		{value: " 3 ", wantDelay: 3 * time.Second, wantOK: true},
+		{value: "0", wantDelay: 0, wantOK: true},
+		{value: now.Add(90 * time.Second).Format(http.TimeFormat), wantDelay: 90 * time.Second, wantOK: true},
+		{value: now.Add(-time.Hour).Format(http.TimeFormat), wantDelay: 0, wantOK: true},
+		{value: "-5", wantOK: false},
+		{value: "soon", wantOK: false},
+		{value: "", wantOK: false},
+	}
+	for _, tt := range tests {
+		delay, ok := parseRetryAfter(tt.value, now)
+		if delay != tt.wantDelay || ok != tt.wantOK {
+			t.Errorf("parseRetryAfter(%q) = %v, %v; want %v, %v", tt.value, delay, ok, tt.wantDelay, tt.wantOK)
+		}
+	}
+}
+
+func TestRetryAfterOverridesBackoff(t *testing.T) {
+	attempts := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, authPath) {
+			w.Header().Set("Content-Type", "application/json")
+			w.Write([]byte(`{"access_token":"token","expires_in":3600}`))
+			return
+		}
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Write([]byte("%PDF-1.4"))
+	}))
+	defer srv.Close()
+
+	var slept []time.Duration
+	c, err := NewDHLClient("id", "secret",
+		WithBaseURL(srv.URL),
+		WithRetryPolicy(RetryPolicy{MaxAttempts: 2, BaseDelay: time.Hour, MaxDelay: time.Hour}),
+		WithSleep(func(ctx context.Context, d time.Duration) error {
+			slept = append(slept, d)
+			return nil
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := c.GetItemLabel(context.Background(), "item-1"); err != nil {
+		t.Fatalf("GetItemLabel: %v", err)
+	}
+	if attempts != 2 {
+		t.Errorf("got %d attempts, want 2", attempts)
+	}
+	if len(slept) != 1 || slept[0] != 7*time.Second {
+		t.Errorf("slept %v, want [7s]", slept)
+	}
+}
+
+func TestBackoffBounds(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  RetryPolicy
+		attempt int
+		wantMax time.Duration
+	}{
+		{name: "first retry", policy: RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, attempt: 1, wantMax: time.Second},
+		{name: "doubles", policy: RetryPolicy{BaseDelay: time.Second, MaxDelay: time.Minute}, attempt: 4, wantMax: 8 * time.Second},
+		{name: "capped", policy: RetryPolicy{BaseDelay: time.Second, MaxDelay: 5 * time.Second}, attempt: 10, wantMax: 5 * time.Second},
+		{name: "uncapped without MaxDelay", policy: RetryPolicy{BaseDelay: time.Second}, attempt: 5, wantMax: 16 * time.Second},
+		{name: "no overflow", policy: RetryPolicy{BaseDelay: time.Second}, attempt: 200, wantMax: math.MaxInt64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			jitter := newJitterSource(1)
+			var longest time.Duration
+			for i := 0; i < 1000; i++ {
+				delay := tt.policy.backoff(tt.attempt, jitter)
+				if delay < 0 || delay >= tt.wantMax {
+					t.Fatalf("backoff(%d) = %v, want in [0, %v)", tt.attempt, delay, tt.wantMax)
+				}
+				longest = max(longest, delay)
+			}
+			if longest < tt.wantMax/2 {
+				t.Errorf("longest of 1000 delays is %v, want at least %v", longest, tt.wantMax/2)
+			}
+		})
+	}
+}
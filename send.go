@@ -0,0 +1,288 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+type RoundTripFunc func(req *http.Request) (*http.Response, error)
+
+type Middleware func(next RoundTripFunc) RoundTripFunc
+
+// WithMiddleware wraps every HTTP attempt, retries included, in mw. Earlier
+// middleware is the outer layer. Middleware runs inside the concurrency
+// limit and before request signing and logging.
+func WithMiddleware(mw ...Middleware) Option {
+	return func(c *DHLClient) {
+		c.middleware = append(c.middleware, mw...)
+	}
+}
+
+type RequestSigner func(req *http.Request, body []byte) error
+
+// WithRequestSigner registers a signer that is called with the finalized
+// request and its body bytes right before the request is sent.
+func WithRequestSigner(signer RequestSigner) Option {
+	return func(c *DHLClient) {
+		c.signer = signer
+	}
+}
+
+// WithMaxConcurrency bounds the number of requests in flight at once. Callers
+// beyond the limit block until a slot frees up or their context is done.
+func WithMaxConcurrency(n int) Option {
+	return func(c *DHLClient) {
+		if n > 0 {
+			c.semaphore = make(chan struct{}, n)
+		}
+	}
+}
+
+type opKind int
+
+const (
+	opKindOrder opKind = iota
+	opKindAuth
+	opKindLabel
+	// opKindRender requests go to the ZPL render endpoint, without a token.
+	opKindRender
+)
+
+type opKindKey struct{}
+
+// withOpKind tags ctx with the kind of operation the requests made under it
+// belong to, which selects the HTTP client used to send them.
+func withOpKind(ctx context.Context, kind opKind) context.Context {
+	return context.WithValue(ctx, opKindKey{}, kind)
+}
+
+func opKindFrom(ctx context.Context) opKind {
+	kind, _ := ctx.Value(opKindKey{}).(opKind)
+	return kind
+}
+
+type requestHeadersKey struct{}
+
+// WithRequestHeader returns a context whose requests carry the header key
+// set to value, replacing any value the client would send itself.
+func WithRequestHeader(ctx context.Context, key, value string) context.Context {
+	headers := http.Header{}
+	if existing, ok := ctx.Value(requestHeadersKey{}).(http.Header); ok {
+		headers = existing.Clone()
+	}
+	headers.Set(key, value)
+	return context.WithValue(ctx, requestHeadersKey{}, headers)
+}
+
+func WithAuthHTTPClient(client *http.Client) Option {
+	return func(c *DHLClient) {
+		c.opClients[opKindAuth] = client
+	}
+}
+
+func WithOrderHTTPClient(client *http.Client) Option {
+	return func(c *DHLClient) {
+		c.opClients[opKindOrder] = client
+	}
+}
+
+func WithLabelHTTPClient(client *http.Client) Option {
+	return func(c *DHLClient) {
+		c.opClients[opKindLabel] = client
+	}
+}
+
+func (c *DHLClient) httpClientFor(ctx context.Context) *http.Client {
+	client := c.opClients[opKindFrom(ctx)]
+	if client == nil {
+		client = c.HTTPClient
+	}
+	c.warnIgnoredTransport(client)
+	return client
+}
+
+type callStats struct {
+	attempts int
+}
+
+type callStatsKey struct{}
+
+// withCallStats returns a context under which every HTTP attempt made by the
+// client, including retries, is counted in stats.
+func withCallStats(ctx context.Context, stats *callStats) context.Context {
+	return context.WithValue(ctx, callStatsKey{}, stats)
+}
+
+func (c *DHLClient) send(req *http.Request, body []byte) (*http.Response, error) {
+	req, body, err := c.compressBody(withIdempotencyKey(req), body)
+	if err != nil {
+		return nil, err
+	}
+
+	probe, err := c.allowCall()
+	if err != nil {
+		return nil, err
+	}
+
+	callerCtx := req.Context()
+	req, cancel := applyOpDeadline(req)
+
+	resp, err := c.sendAuthorized(req, body)
+	c.recordCall(probe, resp, err, callerCtx.Err() != nil)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+func (c *DHLClient) sendAuthorized(req *http.Request, body []byte) (*http.Response, error) {
+	resp, err := c.sendWithRetry(req, body)
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	// A rejected token request is answered by the caller, which holds the
+	// token lock that re-authorizing would need. Render requests carry no
+	// token to refresh.
+	if kind := opKindFrom(req.Context()); kind == opKindAuth || kind == opKindRender {
+		return resp, nil
+	}
+
+	// The cached token was rejected, most likely because DHL considers it
+	// expired. Refresh it and try exactly once more.
+	retry, ok := c.reauthorize(req)
+	if !ok {
+		return resp, nil
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+
+	if body != nil {
+		retry.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	return c.sendWithRetry(retry, body)
+}
+
+func (c *DHLClient) sendWithRetry(req *http.Request, body []byte) (*http.Response, error) {
+	ctx := req.Context()
+	policy := c.retryPolicyFor(ctx)
+	if !policy.RetryNonIdempotent && !isIdempotent(req) {
+		policy.MaxAttempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		attemptReq := req.Clone(ctx)
+		if attempt > 1 && body != nil {
+			attemptReq.Body = io.NopCloser(bytes.NewReader(body))
+		}
+
+		if stats, ok := ctx.Value(callStatsKey{}).(*callStats); ok && stats != nil {
+			stats.attempts++
+		}
+
+		resp, err := c.sendOnce(attemptReq, body)
+		if attempt >= policy.MaxAttempts || ctx.Err() != nil {
+			return resp, err
+		}
+		if !c.shouldRetry(resp, err) {
+			return resp, err
+		}
+		delay := policy.backoff(attempt, c.jitter)
+		if resp != nil {
+			if after, ok := parseRetryAfter(resp.Header.Get("Retry-After"), c.now()); ok {
+				delay = after
+			}
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		c.publish(Event{Type: RetryScheduled, Method: req.Method, URL: req.URL.String(), Attempt: attempt + 1, Delay: delay})
+		if err := c.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c *DHLClient) sendOnce(req *http.Request, body []byte) (*http.Response, error) {
+	return c.roundTripper(body)(req)
+}
+
+// roundTripper layers the middleware around the HTTP client: the concurrency
+// limit outermost, then the caller's middleware in registration order, then
+// context header overrides, request signing, logging and event publishing,
+// so signers, logs and events see the final request.
+func (c *DHLClient) roundTripper(body []byte) RoundTripFunc {
+	rt := func(req *http.Request) (*http.Response, error) {
+		return c.httpClientFor(req.Context()).Do(req)
+	}
+
+	layers := []Middleware{c.limitConcurrency}
+	layers = append(layers, c.middleware...)
+	layers = append(layers, overrideHeaders, c.signRequest(body), c.logExchange(body), c.publishExchange)
+
+	for i := len(layers) - 1; i >= 0; i-- {
+		rt = layers[i](rt)
+	}
+	return rt
+}
+
+func (c *DHLClient) limitConcurrency(next RoundTripFunc) RoundTripFunc {
+	if c.semaphore == nil {
+		return next
+	}
+	return func(req *http.Request) (*http.Response, error) {
+		select {
+		case c.semaphore <- struct{}{}:
+			defer func() { <-c.semaphore }()
+		case <-req.Context().Done():
+			return nil, req.Context().Err()
+		}
+		return next(req)
+	}
+}
+
+func overrideHeaders(next RoundTripFunc) RoundTripFunc {
+	return func(req *http.Request) (*http.Response, error) {
+		if headers, ok := req.Context().Value(requestHeadersKey{}).(http.Header); ok {
+			for key, values := range headers {
+				req.Header[key] = append([]string(nil), values...)
+			}
+		}
+		return next(req)
+	}
+}
+
+func (c *DHLClient) signRequest(body []byte) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		if c.signer == nil {
+			return next
+		}
+		return func(req *http.Request) (*http.Response, error) {
+			if err := c.signer(req, body); err != nil {
+				return nil, fmt.Errorf("signing request: %w", err)
+			}
+			return next(req)
+		}
+	}
+}
+
+func (c *DHLClient) logExchange(body []byte) Middleware {
+	return func(next RoundTripFunc) RoundTripFunc {
+		return func(req *http.Request) (*http.Response, error) {
+			c.logRequest(req, body)
+
+			resp, err := next(req)
+			if err != nil {
+				return nil, err
+			}
+
+			c.logResponse(req, resp)
+			return resp, nil
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+type OrderStatus string
+
+const (
+	StatusCreated    OrderStatus = "CREATED"
+	StatusManifested OrderStatus = "MANIFESTED"
+	StatusShipped    OrderStatus = "SHIPPED"
+	StatusInTransit  OrderStatus = "IN_TRANSIT"
+	StatusDelivered  OrderStatus = "DELIVERED"
+	StatusReturned   OrderStatus = "RETURNED"
+	StatusCancelled  OrderStatus = "CANCELLED"
+
+	UnknownStatus OrderStatus = "UNKNOWN"
+)
+
+var orderStatusAliases = map[string]OrderStatus{
+	"CREATED":    StatusCreated,
+	"OPEN":       StatusCreated,
+	"MANIFESTED": StatusManifested,
+	"FINALIZE":   StatusManifested,
+	"FINALIZED":  StatusManifested,
+	"SHIPPED":    StatusShipped,
+	"IN_TRANSIT": StatusInTransit,
+	"INTRANSIT":  StatusInTransit,
+	"DELIVERED":  StatusDelivered,
+	"RETURNED":   StatusReturned,
+	"CANCELLED":  StatusCancelled,
+	"CANCELED":   StatusCancelled,
+	"DELETED":    StatusCancelled,
+}
+
+func ParseOrderStatus(s string) OrderStatus {
+	key := strings.ToUpper(strings.TrimSpace(s))
+	key = strings.NewReplacer(" ", "_", "-", "_").Replace(key)
+	if status, ok := orderStatusAliases[key]; ok {
+		return status
+	}
+	return UnknownStatus
+}
+
+func (s *OrderStatus) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+	*s = ParseOrderStatus(raw)
+	return nil
+}
+
+func (s OrderStatus) IsTerminal() bool {
+	switch s {
+	case StatusDelivered, StatusReturned, StatusCancelled:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// OrderTemplate holds the fields shared by many orders. Fields left empty
+// must be supplied per order.
+type OrderTemplate Order
+
+// ApplyTemplate deep-merges overrides onto template and validates the result.
+// Every non-zero field of overrides wins; zero fields, nil pointers and nil
+// slices inherit the template's value. Nested structs are merged field by
+// field, so overriding only the receiver's name keeps the template address.
+func ApplyTemplate(template OrderTemplate, overrides Order) (Order, error) {
+	merged := mergeValues(reflect.ValueOf(Order(template)), reflect.ValueOf(overrides)).Interface().(Order)
+
+	if err := merged.Validate(); err != nil {
+		return merged, fmt.Errorf("order from template: %w", err)
+	}
+	return merged, nil
+}
+
+func mergeValues(base, override reflect.Value) reflect.Value {
+	switch override.Kind() {
+	case reflect.Struct:
+		if !allFieldsExported(override.Type()) {
+			break
+		}
+		merged := reflect.New(override.Type()).Elem()
+		for i := 0; i < override.NumField(); i++ {
+			merged.Field(i).Set(mergeValues(base.Field(i), override.Field(i)))
+		}
+		return merged
+
+	case reflect.Pointer:
+		if base.IsNil() && override.IsNil() {
+			return override
+		}
+		elemType := override.Type().Elem()
+		baseElem, overrideElem := reflect.Zero(elemType), reflect.Zero(elemType)
+		if !base.IsNil() {
+			baseElem = base.Elem()
+		}
+		if !override.IsNil() {
+			overrideElem = override.Elem()
+		}
+		merged := reflect.New(elemType)
+		merged.Elem().Set(mergeValues(baseElem, overrideElem))
+		return merged
+
+	case reflect.Slice:
+		if !override.IsNil() || base.IsNil() {
+			return override
+		}
+		return reflect.AppendSlice(reflect.MakeSlice(base.Type(), 0, base.Len()), base)
+
+	case reflect.Map:
+		if !override.IsNil() {
+			return override
+		}
+		return base
+	}
+
+	if override.IsZero() {
+		return base
+	}
+	return override
+}
+
+func allFieldsExported(t reflect.Type) bool {
+	for i := 0; i < t.NumField(); i++ {
+		if !t.Field(i).IsExported() {
+			return false
+		}
+	}
+	return true
+}
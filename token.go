@@ -0,0 +1,349 @@
+package main
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const defaultTokenRefreshMargin = 30 * time.Second
+
+const defaultAuthScheme = "Bearer"
+
+const (
+	envClientID     = "DHL_CLIENT_ID"
+	envClientSecret = "DHL_CLIENT_SECRET"
+)
+
+var ErrMissingCredentials = errors.New("dhl client id and client secret are required")
+
+// TokenStore lets an external cache supply and persist the client's access
+// token, e.g. to share one token across processes.
+type TokenStore interface {
+	LoadToken() (token string, expiresAt time.Time, ok bool)
+	SaveToken(token string, expiresAt time.Time)
+}
+
+func WithTokenStore(store TokenStore) Option {
+	return func(c *DHLClient) {
+		c.tokenStore = store
+	}
+}
+
+func NewDHLClientFromEnv(opts ...Option) (*DHLClient, error) {
+	return NewDHLClient(os.Getenv(envClientID), os.Getenv(envClientSecret), opts...)
+}
+
+// NewDHLClientFromBasicAuth creates a client from a base64-encoded
+// "clientID:clientSecret" string, the same value GetAccessToken sends in the
+// Basic Authorization header.
+func NewDHLClientFromBasicAuth(encoded string, opts ...Option) (*DHLClient, error) {
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("decoding basic auth credentials: %w", err)
+	}
+
+	clientID, clientSecret, ok := strings.Cut(string(decoded), ":")
+	if !ok || clientID == "" || clientSecret == "" {
+		return nil, errors.New("decoding basic auth credentials: want base64 of \"clientID:clientSecret\"")
+	}
+
+	return NewDHLClient(clientID, clientSecret, opts...)
+}
+
+// checkCredentials fails construction when a credential is missing, unless
+// the token store already holds a token the client can use.
+func (c *DHLClient) checkCredentials() error {
+	if c.ClientID != "" && c.ClientSecret != "" {
+		return nil
+	}
+	if c.tokenStore != nil {
+		if token, _, ok := c.tokenStore.LoadToken(); ok && token != "" {
+			return nil
+		}
+	}
+
+	var missing []string
+	if c.ClientID == "" {
+		missing = append(missing, "client id")
+	}
+	if c.ClientSecret == "" {
+		missing = append(missing, "client secret")
+	}
+	return fmt.Errorf("%w: missing %s", ErrMissingCredentials, strings.Join(missing, " and "))
+}
+
+type credentials struct {
+	clientID     string
+	clientSecret string
+}
+
+type cachedToken struct {
+	accessToken string
+	expiresAt   time.Time
+	scopes      []string
+}
+
+type credentialsKey struct{}
+
+// WithCredentials returns a context that makes the client authenticate as the
+// given tenant for requests made with it. Tokens are cached per credential
+// pair, so the client's transport and configuration are shared across
+// tenants.
+func WithCredentials(ctx context.Context, clientID, clientSecret string) context.Context {
+	return context.WithValue(ctx, credentialsKey{}, credentials{clientID: clientID, clientSecret: clientSecret})
+}
+
+// WithTokenRefreshMargin sets how long before its expiry a cached token is
+// replaced, 30s by default. Widen it when the local clock runs behind DHL's.
+func WithTokenRefreshMargin(d time.Duration) Option {
+	return func(c *DHLClient) {
+		if d >= 0 {
+			c.refreshMargin = d
+		}
+	}
+}
+
+func WithClock(now func() time.Time) Option {
+	return func(c *DHLClient) {
+		c.now = now
+	}
+}
+
+func (c *DHLClient) credentialsFor(ctx context.Context) credentials {
+	if creds, ok := ctx.Value(credentialsKey{}).(credentials); ok {
+		return creds
+	}
+
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+	return credentials{clientID: c.ClientID, clientSecret: c.ClientSecret}
+}
+
+// UpdateCredentials swaps the client's own credentials and drops the cached
+// token, so the next request authenticates with the new secret. Requests
+// already in flight finish with the token they were sent with; should DHL
+// reject it they re-authenticate with the new credentials.
+func (c *DHLClient) UpdateCredentials(clientID, clientSecret string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	c.ClientID, c.ClientSecret = clientID, clientSecret
+	if c.AccessToken != "" {
+		c.rejectedToken = c.AccessToken
+	}
+	c.AccessToken = ""
+	c.tokenExpiry = time.Time{}
+	c.tokenScopes = nil
+}
+
+type skipAutoRefreshKey struct{}
+
+// SkipAutoRefresh returns a context whose requests are sent with the token
+// the client currently holds, even when it has expired, instead of fetching
+// a new one first. A 401 still triggers the usual single re-authentication.
+// It is meant for tests and for callers that manage tokens themselves.
+func SkipAutoRefresh(ctx context.Context) context.Context {
+	return context.WithValue(ctx, skipAutoRefreshKey{}, true)
+}
+
+// WithAuthScheme replaces "Bearer" as the scheme of the Authorization header
+// sent with the access token, for gateways in front of DHL that expect e.g.
+// "Token". The token request itself always uses Basic auth, so "Basic" is
+// rejected.
+func WithAuthScheme(scheme string) Option {
+	return func(c *DHLClient) {
+		c.authScheme = scheme
+	}
+}
+
+func (c *DHLClient) authorize(req *http.Request) error {
+	token, err := c.ensureToken(req.Context())
+	if err != nil {
+		return fmt.Errorf("obtaining access token: %w", err)
+	}
+
+	req.Header.Set("Authorization", c.authScheme+" "+token)
+	return nil
+}
+
+// reauthorize drops the access token req was sent with from the cache and
+// returns a copy of req authorized with a freshly fetched token. It reports
+// false for requests that did not carry an access token.
+func (c *DHLClient) reauthorize(req *http.Request) (*http.Request, bool) {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), c.authScheme+" ")
+	if !ok || token == "" {
+		return nil, false
+	}
+
+	c.invalidateToken(req.Context(), token)
+
+	retry := req.Clone(context.WithValue(req.Context(), skipAutoRefreshKey{}, false))
+	if err := c.authorize(retry); err != nil {
+		return nil, false
+	}
+	return retry, true
+}
+
+func (c *DHLClient) invalidateToken(ctx context.Context, token string) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if creds, ok := ctx.Value(credentialsKey{}).(credentials); ok {
+		if cached, ok := c.tenantTokens[creds]; ok && cached.accessToken == token {
+			delete(c.tenantTokens, creds)
+		}
+		return
+	}
+
+	if c.AccessToken == token {
+		c.AccessToken = ""
+		c.tokenExpiry = time.Time{}
+	}
+	c.rejectedToken = token
+}
+
+func (c *DHLClient) ensureToken(ctx context.Context) (string, error) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	if skip, _ := ctx.Value(skipAutoRefreshKey{}).(bool); skip {
+		return c.heldToken(ctx)
+	}
+
+	if creds, ok := ctx.Value(credentialsKey{}).(credentials); ok {
+		return c.tenantToken(ctx, creds)
+	}
+
+	if c.AccessToken != "" && c.tokenValid(c.tokenExpiry) {
+		return c.AccessToken, nil
+	}
+	if c.tokenStore != nil {
+		token, expiresAt, ok := c.tokenStore.LoadToken()
+		if ok && token != "" && token != c.rejectedToken && c.tokenValid(expiresAt) {
+			c.AccessToken, c.tokenExpiry, c.tokenScopes = token, expiresAt, nil
+			return token, nil
+		}
+	}
+	return c.refreshToken(ctx)
+}
+
+// heldToken returns the token cached for ctx's credentials without checking
+// its expiry. The caller must hold tokenMu.
+func (c *DHLClient) heldToken(ctx context.Context) (string, error) {
+	token := c.AccessToken
+	if creds, ok := ctx.Value(credentialsKey{}).(credentials); ok {
+		token = c.tenantTokens[creds].accessToken
+	}
+	if token == "" {
+		return "", errors.New("no access token held and auto-refresh is skipped")
+	}
+	return token, nil
+}
+
+// refreshToken fetches a token for the client's own credentials and stores
+// it. The caller must hold tokenMu.
+func (c *DHLClient) refreshToken(ctx context.Context) (string, error) {
+	tokenResp, err := c.fetchToken(ctx, credentials{clientID: c.ClientID, clientSecret: c.ClientSecret})
+	if err != nil {
+		return "", err
+	}
+
+	c.AccessToken = tokenResp.AccessToken
+	c.tokenExpiry = c.expiryFor(tokenResp)
+	c.tokenScopes = strings.Fields(tokenResp.Scope)
+	if c.tokenStore != nil {
+		c.tokenStore.SaveToken(c.AccessToken, c.tokenExpiry)
+	}
+	c.publish(Event{Type: TokenRefreshed, ExpiresAt: c.tokenExpiry})
+	return c.AccessToken, nil
+}
+
+func (c *DHLClient) tenantToken(ctx context.Context, creds credentials) (string, error) {
+	if cached, ok := c.tenantTokens[creds]; ok && c.tokenValid(cached.expiresAt) {
+		return cached.accessToken, nil
+	}
+
+	tokenResp, err := c.fetchToken(ctx, creds)
+	if err != nil {
+		return "", err
+	}
+
+	if c.tenantTokens == nil {
+		c.tenantTokens = make(map[credentials]cachedToken)
+	}
+	c.tenantTokens[creds] = cachedToken{
+		accessToken: tokenResp.AccessToken,
+		expiresAt:   c.expiryFor(tokenResp),
+		scopes:      strings.Fields(tokenResp.Scope),
+	}
+	c.publish(Event{Type: TokenRefreshed, ExpiresAt: c.tenantTokens[creds].expiresAt})
+	return tokenResp.AccessToken, nil
+}
+
+// CurrentToken returns the client's access token, its expiry and whether it
+// is still valid, without fetching a new one. It is meant for diagnostics,
+// such as replaying a request with curl: the token grants full API access
+// until it expires, so keep it out of logs, tickets and shell history.
+func (c *DHLClient) CurrentToken() (token string, expiresAt time.Time, valid bool) {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	return c.AccessToken, c.tokenExpiry, c.AccessToken != "" && c.tokenValid(c.tokenExpiry)
+}
+
+// TokenScopes returns the scopes DHL granted the client's current token. It
+// is nil before the first token fetch, for tokens from a TokenStore and when
+// DHL sent no scope.
+func (c *DHLClient) TokenScopes() []string {
+	c.tokenMu.Lock()
+	defer c.tokenMu.Unlock()
+
+	return append([]string(nil), c.tokenScopes...)
+}
+
+// checkProductScope fails early when the token's scopes name products and
+// code is not among them, rather than letting DHL answer 403. Tokens whose
+// scopes do not name products are not checked.
+func (c *DHLClient) checkProductScope(ctx context.Context, code ProductCode) error {
+	if _, err := c.ensureToken(ctx); err != nil {
+		return fmt.Errorf("obtaining access token: %w", err)
+	}
+
+	c.tokenMu.Lock()
+	scopes := c.tokenScopes
+	if creds, ok := ctx.Value(credentialsKey{}).(credentials); ok {
+		scopes = c.tenantTokens[creds].scopes
+	}
+	c.tokenMu.Unlock()
+
+	var products []string
+	for _, scope := range scopes {
+		if product, ok := strings.CutPrefix(scope, "product:"); ok {
+			if strings.EqualFold(product, string(code)) {
+				return nil
+			}
+			products = append(products, product)
+		}
+	}
+	if len(products) == 0 {
+		return nil
+	}
+	return fmt.Errorf("access token is not authorized for product %s (token covers %s)", code, strings.Join(products, ", "))
+}
+
+func (c *DHLClient) expiryFor(tokenResp TokenResponse) time.Time {
+	if tokenResp.ExpiresIn <= 0 {
+		return time.Time{}
+	}
+	return c.now().Add(time.Duration(tokenResp.ExpiresIn) * time.Second)
+}
+
+func (c *DHLClient) tokenValid(expiresAt time.Time) bool {
+	return expiresAt.IsZero() || c.now().Add(c.refreshMargin).Before(expiresAt)
+}
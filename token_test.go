@@ -0,0 +1,50 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"main.go/dhltest"
+)
+
+func TestReauthorizesOnceAfterUnauthorized(t *testing.T) {
+	tests := []struct {
+		name         string
+		rejections   int
+		wantStatus   int
+		wantAuth     int
+		wantRequests int
+	}{
+		{name: "401 then success", rejections: 1, wantAuth: 2, wantRequests: 2},
+		{name: "401 twice", rejections: 2, wantStatus: http.StatusUnauthorized, wantAuth: 2, wantRequests: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := dhltest.NewServer()
+			defer srv.Close()
+
+			c, err := NewDHLClient("id", "secret", WithBaseURL(srv.BaseURL()))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ctx := context.Background()
+			if err := c.GetAccessToken(ctx); err != nil {
+				t.Fatal(err)
+			}
+			srv.InjectError(dhltest.RouteItemLabel, http.StatusUnauthorized, `{"title":"Unauthorized"}`, tt.rejections)
+
+			_, err = c.GetItemLabel(ctx, "item-1")
+			var apiErr *APIError
+			switch {
+			case tt.wantStatus == 0 && err != nil:
+				t.Fatalf("GetItemLabel: %v", err)
+			case tt.wantStatus != 0 && (!errors.As(err, &apiErr) || apiErr.StatusCode != tt.wantStatus):
+				t.Fatalf("GetItemLabel error = %v, want status %d", err, tt.wantStatus)
+			}
+			srv.AssertReceived(t, dhltest.RouteAuth, tt.wantAuth)
+			srv.AssertReceived(t, dhltest.RouteItemLabel, tt.wantRequests)
+		})
+	}
+}
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var s10Weights = [8]int{8, 6, 4, 2, 3, 5, 9, 7}
+
+// ValidateTrackingNumber checks the format and check digit of a tracking
+// number without contacting DHL. Supported are UPU S10 numbers used by DHL
+// eCommerce International products (e.g. RR123456785DE, mod-11), 12-digit
+// DHL Paket Identcodes (mod-10, weights 4/9) and 20-digit SSCC-style
+// numbers (GS1 mod-10).
+func ValidateTrackingNumber(tn string) error {
+	tn = strings.ToUpper(strings.ReplaceAll(tn, " ", ""))
+
+	switch {
+	case len(tn) == 13 && isLetters(tn[:2]) && isDigits(tn[2:11]) && isLetters(tn[11:]):
+		return checkDigit(tn, int(tn[10]-'0'), s10CheckDigit(tn[2:10]))
+	case len(tn) == 12 && isDigits(tn):
+		return checkDigit(tn, int(tn[11]-'0'), identcodeCheckDigit(tn[:11]))
+	case len(tn) == 20 && isDigits(tn):
+		return checkDigit(tn, int(tn[19]-'0'), gs1CheckDigit(tn[:19]))
+	}
+	return fmt.Errorf("tracking number %q has an unrecognized format", tn)
+}
+
+func checkDigit(tn string, got, want int) error {
+	if got != want {
+		return fmt.Errorf("tracking number %q has check digit %d, want %d", tn, got, want)
+	}
+	return nil
+}
+
+func s10CheckDigit(serial string) int {
+	sum := 0
+	for i := 0; i < 8; i++ {
+		sum += int(serial[i]-'0') * s10Weights[i]
+	}
+	switch check := 11 - sum%11; check {
+	case 10:
+		return 0
+	case 11:
+		return 5
+	default:
+		return check
+	}
+}
+
+func identcodeCheckDigit(digits string) int {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		weight := 4
+		if i%2 == 1 {
+			weight = 9
+		}
+		sum += int(digits[i]-'0') * weight
+	}
+	return (10 - sum%10) % 10
+}
+
+func gs1CheckDigit(digits string) int {
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		weight := 1
+		if (len(digits)-i)%2 == 1 {
+			weight = 3
+		}
+		sum += int(digits[i]-'0') * weight
+	}
+	return (10 - sum%10) % 10
+}
+
+func isDigits(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isLetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'A' || s[i] > 'Z' {
+			return false
+		}
+	}
+	return s != ""
+}
+
+const getTrackingPath = "/tracking/v1/trackings/%s"
+
+type TrackingEvent struct {
+	StatusCode  string    `json:"statusCode"`
+	Description string    `json:"description"`
+	Location    string    `json:"location"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+func (e *TrackingEvent) UnmarshalJSON(data []byte) error {
+	var raw struct {
+		StatusCode  string `json:"statusCode"`
+		Description string `json:"description"`
+		Location    string `json:"location"`
+		Timestamp   string `json:"timestamp"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	timestamp, err := parseDHLTime(raw.Timestamp)
+	if err != nil {
+		return fmt.Errorf("tracking event timestamp: %w", err)
+	}
+
+	*e = TrackingEvent{
+		StatusCode:  raw.StatusCode,
+		Description: raw.Description,
+		Location:    raw.Location,
+		Timestamp:   timestamp,
+	}
+	return nil
+}
+
+// TrackingStatus is the tracking state of a parcel and its event history.
+type TrackingStatus struct {
+	TrackingNumber string          `json:"trackingNumber"`
+	Status         string          `json:"status"`
+	Events         []TrackingEvent `json:"events"`
+
+	// EstimatedDelivery is DHL's current delivery estimate.
+	// HasEstimatedDelivery is false while DHL has none, e.g. before the
+	// parcel is scanned into the network.
+	EstimatedDelivery    time.Time `json:"estimatedDelivery"`
+	HasEstimatedDelivery bool      `json:"-"`
+}
+
+type trackingPage struct {
+	TrackingStatus
+	EstimatedDelivery string `json:"estimatedDelivery"`
+	Page              int    `json:"page"`
+	TotalPages        int    `json:"totalPages"`
+}
+
+// GetTrackingStatus returns the tracking status with the complete event
+// history, following DHL's pagination until all pages have been fetched.
+func (c *DHLClient) GetTrackingStatus(ctx context.Context, trackingNumber string) (_ *TrackingStatus, err error) {
+	defer wrapOp(&err, opGetTracking)
+
+	var status *TrackingStatus
+	for page := 1; ; page++ {
+		p, err := c.getTrackingPage(ctx, trackingNumber, page)
+		if err != nil {
+			return nil, err
+		}
+
+		if status == nil {
+			status = &p.TrackingStatus
+		} else {
+			status.Events = append(status.Events, p.Events...)
+		}
+
+		if page >= p.TotalPages {
+			return status, nil
+		}
+	}
+}
+
+func (c *DHLClient) getTrackingPage(ctx context.Context, trackingNumber string, page int) (*trackingPage, error) {
+	u := c.url(getTrackingPath, trackingNumber)
+	if page > 1 {
+		u += "?page=" + strconv.Itoa(page)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return nil, err
+	}
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newAPIError(resp)
+	}
+
+	var p trackingPage
+	if err := json.NewDecoder(resp.Body).Decode(&p); err != nil {
+		return nil, fmt.Errorf("decoding response: %w", err)
+	}
+
+	estimate, err := parseDHLTime(p.EstimatedDelivery)
+	if err != nil {
+		return nil, fmt.Errorf("decoding response: estimatedDelivery: %w", err)
+	}
+	p.TrackingStatus.EstimatedDelivery = estimate
+	p.TrackingStatus.HasEstimatedDelivery = !estimate.IsZero()
+
+	return &p, nil
+}
+
+var dhlTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05",
+	time.DateOnly,
+}
+
+// parseDHLTime parses the timestamp formats DHL uses. Timestamps without a
+// zone are in UTC.
+func parseDHLTime(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	for _, layout := range dhlTimeLayouts {
+		if t, err := time.ParseInLocation(layout, value, time.UTC); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized time %q", value)
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	watchMinInterval = 2 * time.Second
+	watchMaxInterval = 30 * time.Second
+)
+
+// OrderUpdate is one event of WatchOrderUpdates: a status change, or the
+// error that ended the watch, which is always the last update.
+type OrderUpdate struct {
+	Status OrderStatus
+	Err    error
+}
+
+// WatchOrder polls GetOrder and emits each status change on the returned
+// channel. The poll interval starts at watchMinInterval, grows while the
+// status stays the same or polls fail transiently and resets after a change.
+// The channel is closed once a terminal status is seen, ctx is done or a
+// poll fails permanently, e.g. because the order was deleted; use
+// WatchOrderUpdates to learn why.
+func (c *DHLClient) WatchOrder(ctx context.Context, orderID string) (_ <-chan OrderStatus, err error) {
+	defer wrapOp(&err, opWatchOrder)
+
+	updates, err := c.WatchOrderUpdates(ctx, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := make(chan OrderStatus, 1)
+	go func() {
+		defer close(statuses)
+		for update := range updates {
+			if update.Err != nil {
+				continue
+			}
+			select {
+			case statuses <- update.Status:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	return statuses, nil
+}
+
+// WatchOrderUpdates is like WatchOrder, but a poll that fails permanently is
+// delivered as a last update carrying the error before the channel is
+// closed. When ctx is done the channel is closed without one.
+func (c *DHLClient) WatchOrderUpdates(ctx context.Context, orderID string) (_ <-chan OrderUpdate, err error) {
+	defer wrapOp(&err, opWatchOrder)
+
+	order, err := c.GetOrder(ctx, orderID)
+	if err != nil {
+		return nil, fmt.Errorf("watching order %s: %w", orderID, err)
+	}
+
+	updates := make(chan OrderUpdate, 1)
+	updates <- OrderUpdate{Status: order.Status}
+	if order.Status.IsTerminal() {
+		close(updates)
+		return updates, nil
+	}
+
+	go func() {
+		defer close(updates)
+
+		send := func(update OrderUpdate) bool {
+			select {
+			case updates <- update:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		last := order.Status
+		interval := watchMinInterval
+
+		for {
+			if err := c.sleep(ctx, interval); err != nil {
+				return
+			}
+
+			order, err := c.GetOrder(ctx, orderID)
+			switch {
+			case err != nil && ctx.Err() != nil:
+				return
+			case err != nil && !isTransient(err):
+				send(OrderUpdate{Err: fmt.Errorf("watching order %s: %w", orderID, err)})
+				return
+			case err != nil:
+				interval = nextWatchInterval(interval)
+			case order.Status != last:
+				last = order.Status
+				interval = watchMinInterval
+				if !send(OrderUpdate{Status: last}) || last.IsTerminal() {
+					return
+				}
+			default:
+				interval = nextWatchInterval(interval)
+			}
+		}
+	}()
+
+	return updates, nil
+}
+
+// isTransient reports whether a failed call may succeed when repeated:
+// network errors, an open circuit and retryable HTTP statuses. Rejections
+// such as 404 or failed authentication are permanent.
+func isTransient(err error) bool {
+	var apiErr *APIError
+	var authErr *AuthError
+	switch {
+	case errors.Is(err, ErrCircuitOpen):
+		return true
+	case errors.As(err, &authErr), errors.Is(err, ErrMissingCredentials):
+		return false
+	case errors.As(err, &apiErr):
+		return isRetryableStatus(apiErr.StatusCode)
+	}
+	return true
+}
+
+func nextWatchInterval(d time.Duration) time.Duration {
+	d = d * 3 / 2
+	if d > watchMaxInterval {
+		return watchMaxInterval
+	}
+	return d
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+const (
+	createWebhookPath = "/shipping/v1/webhooks"
+	deleteWebhookPath = "/shipping/v1/webhooks/%s"
+)
+
+type webhookSubscriptionRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"`
+}
+
+type webhookSubscriptionResponse struct {
+	ID string `json:"id"`
+}
+
+// CreateWebhookSubscription registers endpoint to receive DHL's shipment
+// event pushes and returns the subscription id. An empty events subscribes
+// to all events.
+func (c *DHLClient) CreateWebhookSubscription(ctx context.Context, endpoint string, events []string) (_ string, err error) {
+	defer wrapOp(&err, opCreateWebhook)
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return "", fmt.Errorf("webhook endpoint %q must be an absolute https URL", endpoint)
+	}
+
+	jsonData, err := c.marshal(webhookSubscriptionRequest{URL: endpoint, Events: events})
+	if err != nil {
+		return "", fmt.Errorf("marshaling subscription: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.url(createWebhookPath), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return "", err
+	}
+	req.Header.Add("Content-Type", c.contentType)
+	req.Header.Add("Accept", "application/json")
+
+	resp, err := c.send(req, jsonData)
+	if err != nil {
+		return "", fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
+		return "", newAPIError(resp)
+	}
+
+	var subscription webhookSubscriptionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&subscription); err != nil {
+		return "", fmt.Errorf("decoding response: %w", err)
+	}
+	if subscription.ID == "" {
+		return "", errors.New("DHL returned no subscription id")
+	}
+
+	return subscription.ID, nil
+}
+
+func (c *DHLClient) DeleteWebhookSubscription(ctx context.Context, id string) (err error) {
+	defer wrapOp(&err, opDeleteWebhook)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, c.url(deleteWebhookPath, url.PathEscape(id)), nil)
+	if err != nil {
+		return fmt.Errorf("creating request: %w", err)
+	}
+
+	if err := c.authorize(req); err != nil {
+		return err
+	}
+
+	resp, err := c.send(req, nil)
+	if err != nil {
+		return fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		return newAPIError(resp)
+	}
+
+	return nil
+}
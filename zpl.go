@@ -0,0 +1,79 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// zplRenderURLFormat renders a 4x6in label at the given dots/mm.
+const zplRenderURLFormat = "https://api.labelary.com/v1/printers/%s/labels/4x6/0/"
+
+// zplDensities maps the label resolutions LabelOptions.DPI accepts to
+// Labelary's print densities.
+var zplDensities = map[int]string{
+	203: "8dpmm",
+	300: "12dpmm",
+}
+
+// WithZPLRenderURL points RenderZPLToPNG at a different Labelary-compatible
+// endpoint, e.g. a self-hosted renderer. The URL is used as is for every
+// resolution.
+func WithZPLRenderURL(url string) Option {
+	return func(c *DHLClient) {
+		c.zplRenderURL = url
+	}
+}
+
+// RenderZPLToPNG renders a ZPL label of DHL's default 203 dpi to a PNG
+// preview. The ZPL is posted to the render endpoint, not to DHL, so no DHL
+// credentials are sent along; otherwise it is sent like any other request,
+// through the middleware and with the retry policy.
+func (c *DHLClient) RenderZPLToPNG(ctx context.Context, zpl []byte) ([]byte, error) {
+	return c.RenderZPLToPNGAtDPI(ctx, zpl, defaultLabelDPI)
+}
+
+// RenderZPLToPNGAtDPI is RenderZPLToPNG for labels requested with
+// LabelOptions.DPI, 203 or 300, so they preview at the right scale.
+func (c *DHLClient) RenderZPLToPNGAtDPI(ctx context.Context, zpl []byte, dpi int) (_ []byte, err error) {
+	defer wrapOp(&err, opRenderZPL)
+
+	density, ok := zplDensities[dpi]
+	if !ok {
+		return nil, fmt.Errorf("unsupported label resolution %d dpi (want 203 or 300)", dpi)
+	}
+	url := c.zplRenderURL
+	if url == "" {
+		url = fmt.Sprintf(zplRenderURLFormat, density)
+	}
+
+	req, err := http.NewRequestWithContext(withOpKind(ctx, opKindRender), http.MethodPost, url, bytes.NewReader(zpl))
+	if err != nil {
+		return nil, fmt.Errorf("creating request: %w", err)
+	}
+	req.Header.Add("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Add("Accept", "image/png")
+
+	resp, err := c.send(req, zpl)
+	if err != nil {
+		return nil, fmt.Errorf("sending request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("unexpected status code: %d, body: %s", resp.StatusCode, truncateBody(body, maxErrorBodySize))
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("reading response body: %w", err)
+	}
+	if !bytes.HasPrefix(data, pngMagic) {
+		return nil, fmt.Errorf("render endpoint returned %q, not a PNG", resp.Header.Get("Content-Type"))
+	}
+
+	return data, nil
+}